@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/malikbenkirane/groq-whisper/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newCommandConfig(s *settings) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the groq config file",
+	}
+	cmd.AddCommand(
+		newCommandConfigShow(s),
+		newCommandConfigValidate(s),
+		newCommandConfigInit(s))
+	return cmd
+}
+
+func newCommandConfigShow(s *settings) *cobra.Command {
+	var env *bool
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Print the resolved config",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if *env {
+				for _, name := range config.Env() {
+					fmt.Println(name)
+				}
+				return nil
+			}
+			if err := s.Config.Load(*s.path, cmd.Flags()); err != nil {
+				return fmt.Errorf("load config: %w", err)
+			}
+			// the secrets are not printed
+			for _, secret := range []*string{&s.Groq.Key, &s.Serf.Key} {
+				if *secret != "" {
+					*secret = "REDACTED"
+				}
+			}
+			b, err := s.Config.Marshal()
+			if err != nil {
+				return fmt.Errorf("marshal config: %w", err)
+			}
+			_, err = os.Stdout.Write(b)
+			return err
+		},
+	}
+	env = cmd.Flags().Bool("env", false, "list the environment variables overriding the config")
+	return cmd
+}
+
+func newCommandConfigValidate(s *settings) *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Check the config file and environment",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := s.load(cmd); err != nil {
+				return err
+			}
+			fmt.Println("config ok")
+			return nil
+		},
+	}
+}
+
+func newCommandConfigInit(s *settings) *cobra.Command {
+	var force *bool
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Write the default config file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := *s.path
+			if p == "" {
+				var err error
+				if p, err = config.DefaultPath(); err != nil {
+					return err
+				}
+			}
+			if _, err := os.Stat(p); err == nil && !*force {
+				return fmt.Errorf("%q exists (use --force to overwrite)", p)
+			} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("stat %q: %w", p, err)
+			}
+			conf, err := config.Default()
+			if err != nil {
+				return fmt.Errorf("default config: %w", err)
+			}
+			b, err := conf.Marshal()
+			if err != nil {
+				return fmt.Errorf("marshal config: %w", err)
+			}
+			if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+				return fmt.Errorf("mkdir %q: %w", filepath.Dir(p), err)
+			}
+			if err := os.WriteFile(p, b, 0600); err != nil {
+				return fmt.Errorf("write %q: %w", p, err)
+			}
+			fmt.Println(p)
+			return nil
+		},
+	}
+	force = cmd.Flags().Bool("force", false, "overwrite an existing config file")
+	return cmd
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/malikbenkirane/groq-whisper/internal/sampler"
+	"github.com/spf13/cobra"
+)
+
+func newCommandDevices() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "devices",
+		Short: "Audio input devices",
+	}
+	cmd.AddCommand(newCommandDevicesList())
+	return cmd
+}
+
+func newCommandDevicesList() *cobra.Command {
+	var asJson *bool
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the input devices with their channels and sample rates",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			devices, err := sampler.Devices()
+			if err != nil {
+				return err
+			}
+			if *asJson {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(devices)
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "INDEX\tNAME\tAPI\tCHANNELS\tRATES\tDEFAULT")
+			for _, d := range devices {
+				rates := make([]string, len(d.Rates))
+				for i, r := range d.Rates {
+					rates[i] = strconv.FormatFloat(r, 'f', -1, 64)
+				}
+				def := ""
+				if d.Default {
+					def = "*"
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\t%s\n",
+					d.Index, d.Name, d.HostAPI, d.Channels, strings.Join(rates, ","), def)
+			}
+			return w.Flush()
+		},
+	}
+	asJson = cmd.Flags().Bool("json", false, "print the devices as json")
+	return cmd
+}
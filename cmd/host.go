@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/malikbenkirane/groq-whisper/internal/server"
+	"go.uber.org/zap"
+)
+
+// hostClient forwards transcribed chunks to a groq-host session, tagged
+// with the actor speaking into this node's microphone.
+type hostClient struct {
+	url     string
+	session int
+	actor   string
+	node    string
+	client  *http.Client
+}
+
+// newHostClient trusts caFile (e.g. the cert.pem made by groq-host mkcert)
+// on top of the system roots when it is set.
+func newHostClient(url string, session int, actor, node, caFile string) (*hostClient, error) {
+	client, err := newHttpClient(caFile)
+	if err != nil {
+		return nil, err
+	}
+	return &hostClient{
+		url:     strings.TrimSuffix(url, "/"),
+		session: session,
+		actor:   actor,
+		node:    node,
+		client:  client,
+	}, nil
+}
+
+// newHttpClient trusts caFile on top of the system roots when it is set.
+func newHttpClient(caFile string) (*http.Client, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	if caFile == "" {
+		return client, nil
+	}
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("read %q: %w", caFile, err)
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in %q", caFile)
+	}
+	client.Transport = &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: roots},
+	}
+	return client, nil
+}
+
+// track is who speaks into the channel of a chunk, channel is 0 unless the
+// sampler splits the channels.
+type track struct {
+	channel int
+	actor   string
+}
+
+// newTrack resolves the actor of the chunk name from sampler.channel_actors.
+func newTrack(name string, actors []string) track {
+	t := track{channel: sampleChannel(name)}
+	if t.channel > 0 && t.channel <= len(actors) {
+		t.actor = actors[t.channel-1]
+	}
+	return t
+}
+
+// label names the speaker of t in the local transcript.
+func (t track) label() string {
+	if t.actor != "" {
+		return t.actor
+	}
+	return fmt.Sprintf("ch%d", t.channel)
+}
+
+// boundActor reads the actor groq-host bound to the recorder node from
+// GET /record of its api.
+func boundActor(nc nodeClient) (string, error) {
+	res, err := nc.do(http.MethodGet, "/record", nil)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	var status server.RecordStatus
+	if err := json.NewDecoder(res.Body).Decode(&status); err != nil {
+		return "", fmt.Errorf("json decode record status: %w", err)
+	}
+	return status.Actor, nil
+}
+
+// boundActorRefresh is how long the actor bound to the node is cached,
+// read or not.
+const boundActorRefresh = 30 * time.Second
+
+// boundActors caches the actor bound to the recorder node, a node that
+// can't be read is logged once until it is read again.
+type boundActors struct {
+	nc      nodeClient
+	log     *zap.Logger
+	actor   string
+	read    time.Time
+	failing bool
+}
+
+// get returns the bound actor, empty when there is none or the node was
+// not read.
+func (b *boundActors) get(now time.Time) string {
+	if !b.read.IsZero() && now.Sub(b.read) < boundActorRefresh {
+		return b.actor
+	}
+	b.read = now
+	actor, err := boundActor(b.nc)
+	if err != nil {
+		if !b.failing {
+			b.log.Warn("bound actor unknown, using --actor", zap.Error(err))
+		}
+		b.actor, b.failing = "", true
+		return ""
+	}
+	if b.failing {
+		b.log.Info("bound actor read", zap.String("actor", actor))
+	}
+	b.actor, b.failing = actor, false
+	return actor
+}
+
+// postChunk posts the chunk as spoken by the actor of t, the actor of the
+// client when t is a mono track without actor.
+func (hc hostClient) postChunk(text string, ts time.Time, t track) (err error) {
+	actor, node := hc.actor, hc.node
+	if t.channel == 0 && t.actor != "" {
+		actor = t.actor
+	}
+	if t.channel > 0 {
+		// the node name tells apart the channels without actor
+		actor, node = t.actor, fmt.Sprintf("%s/ch%d", hc.node, t.channel)
+	}
+	var body bytes.Buffer
+	if err = json.NewEncoder(&body).Encode(struct {
+		Tx    string
+		Ts    string
+		Actor string
+		Node  string
+	}{
+		Tx:    text,
+		Ts:    ts.Format("2006-01-02T15:04:05.000"),
+		Actor: actor,
+		Node:  node,
+	}); err != nil {
+		return fmt.Errorf("json encode chunk: %w", err)
+	}
+	url := fmt.Sprintf("%s/transcript/%d", hc.url, hc.session)
+	req, err := http.NewRequest(http.MethodPost, url, &body)
+	if err != nil {
+		return fmt.Errorf("http new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := hc.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post %q: %w", url, err)
+	}
+	defer func() {
+		err = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("post %q: %q", url, resp.Status)
+	}
+	return nil
+}
+
+// sampleTime recovers the chunk timestamp the sampler encodes in sample
+// file names, e.g. 20060102150405,000.flac, falling back to now. The
+// layout parses the milliseconds after the comma.
+func sampleTime(name string) time.Time {
+	base := filepath.Base(name)
+	if i := strings.IndexByte(base, '.'); i > 0 {
+		base = base[:i]
+	}
+	t, err := time.ParseInLocation("20060102150405", base, time.Local)
+	if err != nil {
+		return time.Now()
+	}
+	return t
+}
+
+// sampleChannel recovers the channel of a split chunk name, e.g.
+// 20060102150405,000.ch2.flac or its sealed 20060102150405,000.ch2.flac.age, 0
+// otherwise.
+func sampleChannel(name string) int {
+	parts := strings.Split(strings.TrimSuffix(filepath.Base(name), ".age"), ".")
+	if len(parts) != 3 || !strings.HasPrefix(parts[1], "ch") {
+		return 0
+	}
+	ch, err := strconv.Atoi(strings.TrimPrefix(parts[1], "ch"))
+	if err != nil {
+		return 0
+	}
+	return ch
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/malikbenkirane/groq-whisper/internal/apikey"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// keyCheckTimeout bounds the request validating the key.
+const keyCheckTimeout = 30 * time.Second
+
+// keyChain is the providers of the groq api key, in order. A keyring that
+// can't be set up, e.g. without user config dir, is left out.
+func keyChain(s *settings) (apikey.Chain, error) {
+	chain := apikey.Chain{
+		apikey.Env(apikey.EnvVar),
+		apikey.Value{Source: "config groq.key", Value: s.Groq.Key},
+	}
+	if keyring, err := apikey.DefaultKeyring(); err != nil {
+		slog.Warn("api key keyring left out", "err", err)
+	} else {
+		chain = append(chain, apikey.KeyringProvider{Keyring: keyring})
+	}
+	file := apikey.File{Path: s.Groq.KeyFile}
+	if s.Groq.KeyIdentityFile != "" {
+		var err error
+		if file.Identities, err = readIdentities(s.Groq.KeyIdentityFile); err != nil {
+			return nil, err
+		}
+	}
+	return append(chain, file), nil
+}
+
+func groqKey(s *settings) (string, error) {
+	chain, err := keyChain(s)
+	if err != nil {
+		return "", err
+	}
+	key, _, err := chain.Key()
+	return key, err
+}
+
+func newCommandKey(s *settings) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "key",
+		Short: "Store and check the groq api key",
+		Long: `The api key is read from the first of the GROQ_API_KEY environment
+variable, groq.key of the config, the keyring and groq.key_file.`,
+	}
+	cmd.AddCommand(
+		newCommandKeySet(s),
+		newCommandKeyCheck(s))
+	return cmd
+}
+
+func newCommandKeySet(s *settings) *cobra.Command {
+	var file, noCheck *bool
+	var recipients *[]string
+	cmd := &cobra.Command{
+		Use:   "set",
+		Short: "Store the api key read from stdin in the keyring",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := s.load(cmd); err != nil {
+				return err
+			}
+			if len(*recipients) > 0 && (!*file || !strings.HasSuffix(s.Groq.KeyFile, ".age")) {
+				return fmt.Errorf("--recipient: seals a groq.key_file ending with .age, set with --file")
+			}
+			key, err := readKey(cmd.InOrStdin(), cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			if !*noCheck {
+				ctx, cancel := context.WithTimeout(cmd.Context(), keyCheckTimeout)
+				defer cancel()
+				if err := apikey.Check(ctx, http.DefaultClient, s.Groq.URL, key); err != nil {
+					return fmt.Errorf("check key: %w", err)
+				}
+			}
+			if *file {
+				var rs []age.Recipient
+				if len(*recipients) > 0 {
+					if rs, err = age.ParseRecipients(strings.NewReader(strings.Join(*recipients, "\n"))); err != nil {
+						return fmt.Errorf("--recipient: %w", err)
+					}
+				}
+				if err := apikey.WriteFile(s.Groq.KeyFile, key, rs...); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "key stored in %s\n", s.Groq.KeyFile)
+				return nil
+			}
+			keyring, err := apikey.DefaultKeyring()
+			if err != nil {
+				return fmt.Errorf("keyring: %w", err)
+			}
+			if err := keyring.Set(apikey.Account, key); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "key stored in %s\n", keyring.Name())
+			return nil
+		},
+	}
+	file = cmd.Flags().Bool("file", false, "store the key in groq.key_file instead of the keyring")
+	recipients = cmd.Flags().StringArray("recipient", nil, "age public key sealing the key file (repeatable)")
+	noCheck = cmd.Flags().Bool("no-check", false, "store the key without validating it against groq")
+	cmd.Flags().StringVar(&s.Groq.KeyFile, "key-file", s.Groq.KeyFile, "file holding the groq api key")
+	return cmd
+}
+
+func newCommandKeyCheck(s *settings) *cobra.Command {
+	var offline *bool
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Tell where the api key is read from and validate it against groq",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := s.load(cmd); err != nil {
+				return err
+			}
+			chain, err := keyChain(s)
+			if err != nil {
+				return err
+			}
+			key, from, err := chain.Key()
+			if err != nil {
+				return err
+			}
+			// the file provider warns as it reads the key
+			if _, ok := from.(apikey.File); !ok {
+				if err := apikey.CheckPermissions(s.Groq.KeyFile); errors.Is(err, apikey.ErrWorldReadable) {
+					fmt.Fprintf(cmd.ErrOrStderr(), "warning: %s\n", err)
+				}
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "key from %s\n", from.Name())
+			if *offline {
+				return nil
+			}
+			ctx, cancel := context.WithTimeout(cmd.Context(), keyCheckTimeout)
+			defer cancel()
+			if err := apikey.Check(ctx, http.DefaultClient, s.Groq.URL, key); err != nil {
+				return fmt.Errorf("check key: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "key ok")
+			return nil
+		},
+	}
+	offline = cmd.Flags().Bool("offline", false, "only resolve the key")
+	cmd.Flags().StringVar(&s.Groq.KeyFile, "key-file", s.Groq.KeyFile, "file holding the groq api key")
+	return cmd
+}
+
+// readKey reads the key from the terminal without echo, or the first line
+// of stdin when it is not a terminal.
+func readKey(stdin io.Reader, prompt io.Writer) (string, error) {
+	if f, ok := stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fmt.Fprint(prompt, "groq api key: ")
+		b, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(prompt)
+		if err != nil {
+			return "", fmt.Errorf("read key: %w", err)
+		}
+		return validKey(string(b))
+	}
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("read key: %w", err)
+	}
+	return validKey(line)
+}
+
+func validKey(key string) (string, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", errors.New("empty key")
+	}
+	return key, nil
+}
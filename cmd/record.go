@@ -3,63 +3,85 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
-	"path"
 	"syscall"
 	"time"
 
 	"github.com/malikbenkirane/groq-whisper/internal/sampler"
-
 	"github.com/spf13/cobra"
 )
 
-func newCommandRecord() (*cobra.Command, error) {
-	var freq *int
-	var sys32 *bool
-	var samplesDir *string
-
+func newCommandRecord(s *settings) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "record",
 		Aliases: []string{"rec", "r"},
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			if err := s.load(cmd); err != nil {
+				return err
+			}
 
 			quit := make(chan os.Signal, 1)
 			signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
-			encoderOpts := []sampler.EncoderOption{}
-			if *sys32 {
-				encoderOpts = append(encoderOpts,
-					sampler.EncoderOptionPath(
-						"C:\\Windows\\System32\\groq\\groq-deps\\bin\\ffmpeg"))
+			if err := os.MkdirAll(s.Sampler.Root, 0700); err != nil {
+				return fmt.Errorf("mkdir %q: %w", s.Sampler.Root, err)
 			}
 
-			if err := os.MkdirAll(*samplesDir, 0700); err != nil {
-				return fmt.Errorf("mkdir %q: %w", *samplesDir, err)
-			}
-			encoderOpts = append(encoderOpts, sampler.EncoderOptionRoot(*samplesDir))
-
 			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
 
-			s := sampler.New(float64(*freq), time.Duration(time.Second*10), encoderOpts...)
-			go s.Sample(ctx)
+			smp, err := newSampler(s)
+			if err != nil {
+				return err
+			}
+			events, unsubscribe := smp.Subscribe()
+			defer unsubscribe()
+			go printSamplerEvents(cmd.OutOrStdout(), events)
 
-			<-quit
-			cancel()
+			var errSample error
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				errSample = smp.Sample(ctx)
+			}()
 
-			return nil
+			select {
+			case <-done:
+				// the capture failed before being signaled
+				return errSample
+			case <-quit:
+			}
+			cancel()
+			if !waitShutdown(quit, done) {
+				return nil
+			}
+			return errSample
 		},
 	}
 
-	defaultDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("user home dir: %w", err)
-	}
-	defaultDir = path.Join(defaultDir, "groq-whisper-samples")
+	cmd.Flags().IntVarP(&s.Sampler.Rate, "freq", "f", s.Sampler.Rate, "sample rate of the chunks, the input is resampled to it")
+	cmd.Flags().BoolVar(&s.Encoder.Sys32, "ffmpeg-sys32", s.Encoder.Sys32, "use ffmpeg from windows/sys32/groq-deps")
+	cmd.Flags().StringVar(&s.Sampler.Root, "samples-dir", s.Sampler.Root, "where recorded samples are processed")
+	bindInputFlags(cmd, s)
 
-	freq = cmd.Flags().IntP("freq", "f", 16000, "sample rate")
-	sys32 = cmd.Flags().Bool("ffmpeg-sys32", true, "use ffmpeg from windows/sys32/groq-deps")
-	samplesDir = cmd.Flags().String("samples-dir", defaultDir, "where recorded samples are processed")
+	return cmd
+}
 
-	return cmd, nil
+// printSamplerEvents prints a line per sampler event until unsubscribed.
+func printSamplerEvents(w io.Writer, events <-chan sampler.Event) {
+	for ev := range events {
+		line := fmt.Sprintf("%s %s", ev.At.Format(time.TimeOnly), ev.Kind)
+		if ev.Chunk != "" {
+			line += " " + ev.Chunk
+		}
+		if ev.Actor != "" {
+			line += " (" + ev.Actor + ")"
+		}
+		if ev.Err != nil {
+			line += ": " + ev.Err.Error()
+		}
+		fmt.Fprintln(w, line)
+	}
 }
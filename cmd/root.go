@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/malikbenkirane/groq-whisper/internal/config"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
@@ -15,27 +16,48 @@ func NewCLI() (*cobra.Command, error) {
 		},
 	}
 
-	record, err := newCommandRecord()
+	conf, err := config.Default()
 	if err != nil {
-		return nil, fmt.Errorf("new command record: %w", err)
-	}
-
-	sidecar, err := newCommandSidecar()
-	if err != nil {
-		return nil, fmt.Errorf("new command sidecar: %w", err)
+		return nil, fmt.Errorf("default config: %w", err)
 	}
+	s := &settings{Config: conf}
+	s.path = cmd.PersistentFlags().String("config", "",
+		"config file (default is config.yaml in the groq user config dir)")
 
 	cmd.AddCommand(
-		sidecar,
+		newCommandSidecar(s),
 		newCommandVersion(),
 		newCommandUpgrade(),
 		newCommandDev(),
-		newCommandServe(),
-		record)
+		newCommandServe(s),
+		newCommandSwarm(),
+		newCommandConfig(s),
+		newCommandRecord(s),
+		newCommandDevices(),
+		newCommandSamples(s),
+		newCommandTranscribe(s),
+		newCommandKey(s))
 
 	return cmd, nil
 }
 
+// settings is the config shared by the commands. Their flags are bound to
+// its fields and load resolves it once the flags are parsed.
+type settings struct {
+	config.Config
+	path *string
+}
+
+func (s *settings) load(cmd *cobra.Command) error {
+	if err := s.Config.Load(*s.path, cmd.Flags()); err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	if err := s.Config.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
+
 func newLogger(debug bool) *zap.Logger {
 	config := zap.NewProductionConfig()
 	if debug {
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/malikbenkirane/groq-whisper/internal/config"
+	"github.com/malikbenkirane/groq-whisper/internal/retention"
+	"github.com/spf13/cobra"
+)
+
+func newCommandSamples(s *settings) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "samples",
+		Short: "Inspect and prune the recorded chunks and transcripts",
+	}
+	cmd.AddCommand(
+		newCommandSamplesLs(s),
+		newCommandSamplesDu(s),
+		newCommandSamplesPrune(s))
+	cmd.PersistentFlags().StringVar(&s.Sampler.Root, "samples-dir", s.Sampler.Root, "where recorded samples are written")
+	return cmd
+}
+
+func newCommandSamplesLs(s *settings) *cobra.Command {
+	var asJson *bool
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List the chunks and transcripts, the oldest first",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := s.load(cmd); err != nil {
+				return err
+			}
+			entries, err := retention.Scan(s.Sampler.Root)
+			if err != nil {
+				return err
+			}
+			if *asJson {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(entries)
+			}
+			return printEntries(entries)
+		},
+	}
+	asJson = cmd.Flags().Bool("json", false, "print the entries as json")
+	return cmd
+}
+
+func newCommandSamplesDu(s *settings) *cobra.Command {
+	return &cobra.Command{
+		Use:   "du",
+		Short: "Summarize the disk usage of the chunks and transcripts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := s.load(cmd); err != nil {
+				return err
+			}
+			entries, err := retention.Scan(s.Sampler.Root)
+			if err != nil {
+				return err
+			}
+			type usage struct {
+				entries, acked int
+				size           int64
+				oldest         time.Time
+			}
+			usages := make([]usage, 2)
+			for _, e := range entries {
+				u := &usages[e.Kind]
+				if u.entries == 0 {
+					u.oldest = e.Time
+				}
+				u.entries++
+				u.size += e.Size
+				if e.Acked {
+					u.acked++
+				}
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "KIND\tENTRIES\tACKED\tSIZE\tOLDEST")
+			for kind, u := range usages {
+				oldest := "-"
+				if u.entries > 0 {
+					oldest = u.oldest.Format(time.DateTime)
+				}
+				fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\n",
+					retention.Kind(kind), u.entries, u.acked, humanSize(u.size), oldest)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newCommandSamplesPrune(s *settings) *cobra.Command {
+	var dry *bool
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete the chunks and transcripts out of the retention limits",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := s.load(cmd); err != nil {
+				return err
+			}
+			p := retentionPolicy(s.Retention)
+			var (
+				removed []retention.Entry
+				err     error
+			)
+			if *dry {
+				entries, errScan := retention.Scan(s.Sampler.Root)
+				if errScan != nil {
+					return errScan
+				}
+				removed = p.Plan(entries, time.Now())
+			} else {
+				removed, err = retention.Prune(s.Sampler.Root, p, time.Now())
+			}
+			if errPrint := printEntries(removed); errPrint != nil {
+				return errPrint
+			}
+			var size int64
+			for _, e := range removed {
+				size += e.Size
+			}
+			verb := "removed"
+			if *dry {
+				verb = "would remove"
+			}
+			fmt.Printf("%s %d entries, %s\n", verb, len(removed), humanSize(size))
+			return err
+		},
+	}
+	dry = cmd.Flags().Bool("dry-run", false, "only list what would be removed")
+	r := &s.Retention
+	cmd.Flags().Var(&r.MaxAge, "max-age", "delete the chunks older than this")
+	cmd.Flags().Var(&r.MaxSize, "max-size", "delete the oldest chunks above this size (e.g. 2GiB)")
+	cmd.Flags().IntVar(&r.MaxCount, "max-count", r.MaxCount, "delete the oldest chunks above this count")
+	cmd.Flags().Var(&r.TranscriptsMaxAge, "transcripts-max-age", "delete the transcripts older than this")
+	cmd.Flags().Var(&r.TranscriptsMaxSize, "transcripts-max-size", "delete the oldest transcripts above this size")
+	cmd.Flags().IntVar(&r.TranscriptsMaxCount, "transcripts-max-count", r.TranscriptsMaxCount, "delete the oldest transcripts above this count")
+	cmd.Flags().BoolVar(&r.DeleteAcked, "delete-acked", r.DeleteAcked, "delete the chunks transcribed and acknowledged by the host")
+	return cmd
+}
+
+// retentionPolicy is the policy of the retention section.
+func retentionPolicy(r config.Retention) retention.Policy {
+	return retention.Policy{
+		Audio: retention.Limits{
+			MaxAge:   time.Duration(r.MaxAge),
+			MaxSize:  int64(r.MaxSize),
+			MaxCount: r.MaxCount,
+		},
+		Transcripts: retention.Limits{
+			MaxAge:   time.Duration(r.TranscriptsMaxAge),
+			MaxSize:  int64(r.TranscriptsMaxSize),
+			MaxCount: r.TranscriptsMaxCount,
+		},
+		DeleteAcked: r.DeleteAcked,
+	}
+}
+
+func printEntries(entries []retention.Entry) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tKIND\tTIME\tSIZE\tACKED")
+	for _, e := range entries {
+		acked := ""
+		if e.Acked {
+			acked = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			e.Name, e.Kind, e.Time.Format(time.DateTime), humanSize(e.Size), acked)
+	}
+	return w.Flush()
+}
+
+// humanSize formats n bytes with a binary unit, e.g. 1.5 MiB.
+func humanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -3,35 +3,40 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
-	"path"
+	"strings"
 	"syscall"
 	"time"
 
+	"filippo.io/age"
+	"github.com/malikbenkirane/groq-whisper/internal/config"
+	"github.com/malikbenkirane/groq-whisper/internal/retention"
 	"github.com/malikbenkirane/groq-whisper/internal/sampler"
 	"github.com/malikbenkirane/groq-whisper/internal/server"
 	"github.com/spf13/cobra"
 )
 
-func newCommandServe() *cobra.Command {
-	var serfMaster, httpAddr, root *string
-	var serfPort *int
-	var sys32, loop *bool
+func newCommandServe(s *settings) *cobra.Command {
+	var loop *bool
 	cmd := &cobra.Command{
 		Use: "serve",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := os.MkdirAll(*root, 0700); err != nil {
-				return fmt.Errorf("mkdir all %q: %w", *root, err)
+			if err := s.load(cmd); err != nil {
+				return err
+			}
+			root := s.Sampler.Root
+			if err := os.MkdirAll(root, 0700); err != nil {
+				return fmt.Errorf("mkdir all %q: %w", root, err)
 			}
 
-			opts := []server.Option{}
-			if !*sys32 {
-				sampler := sampler.New(
-					16000,
-					time.Second*10,
-					sampler.EncoderOptionRoot(*root))
-				opts = append(opts, server.OptionSampler(sampler))
+			smp, err := newSampler(s)
+			if err != nil {
+				return err
+			}
+			opts := []server.Option{
+				server.OptionSampler(smp),
 			}
 
 			if !*loop {
@@ -40,12 +45,48 @@ func newCommandServe() *cobra.Command {
 					server.OptionNoLoop())
 			}
 
+			master := s.Serf.Join
+			if s.Serf.Swarm != "" && master == config.DefaultJoin {
+				// the swarm is discovered rather than joined at the default
+				master = ""
+			}
 			opts = append(opts,
-				server.OptionSerfMaster(*serfMaster),
-				server.OptionSerfPort(*serfPort),
-				server.OptionHttpAddr(*httpAddr))
+				server.OptionSerfMaster(master),
+				server.OptionSerfPort(s.Serf.Port),
+				server.OptionHttpAddr(s.HTTP.Addr),
+				server.OptionActor(s.Serf.Actor),
+				server.OptionTheme(s.Serf.Theme),
+				server.OptionSwarm(s.Serf.Swarm))
+			if s.Serf.Name != "" {
+				opts = append(opts, server.OptionSerfName(s.Serf.Name))
+			}
+			if s.Serf.Key != "" {
+				key, err := server.DecodeGossipKey(s.Serf.Key)
+				if err != nil {
+					return err
+				}
+				opts = append(opts, server.OptionGossipKey(key))
+			}
+			if s.Serf.KeyringFile != "" {
+				opts = append(opts, server.OptionKeyringFile(s.Serf.KeyringFile))
+			}
+			if s.Serf.AllowPlaintext {
+				opts = append(opts, server.OptionAllowPlaintext())
+			}
+			if s.HTTP.CertFile != "" {
+				opts = append(opts,
+					server.OptionTLS(s.HTTP.CertFile, s.HTTP.KeyFile),
+					server.OptionClientCA(s.HTTP.ClientCA))
+			}
+			if s.HTTP.TokensFile != "" {
+				tokens, err := server.ReadTokens(s.HTTP.TokensFile)
+				if err != nil {
+					return fmt.Errorf("read tokens: %w", err)
+				}
+				opts = append(opts, server.OptionTokens(tokens))
+			}
 
-			sv, err := server.New(*root, opts...)
+			sv, err := server.New(root, opts...)
 			if err != nil {
 				return fmt.Errorf("new server: %w", err)
 			}
@@ -53,30 +94,112 @@ func newCommandServe() *cobra.Command {
 			defer cancel()
 
 			quit := make(chan os.Signal, 1)
-			signal.Notify(quit, syscall.SIGINT, syscall.SIGINT)
+			signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
-			go sv.Serve(ctx)
+			if interval := time.Duration(s.Retention.Interval); interval > 0 {
+				go retention.Run(ctx, root, retentionPolicy(s.Retention), interval)
+			}
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				sv.Serve(ctx)
+			}()
 			<-quit
+			cancel()
+			waitShutdown(quit, done)
 			return nil
 		},
 	}
 
-	defaultRoot, err := os.UserHomeDir()
-	if err != nil {
-		panic("user home dir")
-	}
-	defaultRoot = path.Join(defaultRoot, "Downloads", "groq-whisper-samples")
+	cmd.Flags().StringVar(&s.Sampler.Root, "root-fs", s.Sampler.Root, "server root")
 
-	root = cmd.Flags().String("root-fs", defaultRoot, "server root")
+	cmd.Flags().StringVar(&s.Serf.Join, "serf-master", s.Serf.Join, "groq-host serf address")
+	cmd.Flags().IntVar(&s.Serf.Port, "serf-port", s.Serf.Port, "serf binding port")
+	cmd.Flags().StringVar(&s.Serf.Name, "serf-name", s.Serf.Name, "serf node name (host name if not set)")
 
-	serfMaster = cmd.Flags().String("serf-master", "192.168.117.1:7496", "")
-	serfPort = cmd.Flags().Int("serf-port", 7496, "serf binding port")
+	cmd.Flags().StringVar(&s.Serf.Swarm, "swarm", s.Serf.Swarm, "swarm name advertised over mDNS, joined by discovery unless --serf-master is set (groq-host is not discovered, it joins with --serf-join)")
+	cmd.Flags().StringVar(&s.Serf.Key, "serf-key", s.Serf.Key, "base64 gossip encryption key (groq swarm keygen)")
+	cmd.Flags().StringVar(&s.Serf.KeyringFile, "serf-keyring", s.Serf.KeyringFile, "file persisting the gossip keys across rotations")
+	cmd.Flags().BoolVar(&s.Serf.AllowPlaintext, "serf-plaintext", s.Serf.AllowPlaintext, "allow unencrypted gossip")
 
-	httpAddr = cmd.Flags().String("http-bind", ":7495", "addr bind for the http server")
+	cmd.Flags().StringVar(&s.Serf.Actor, "actor", s.Serf.Actor, "actor speaking into this node's microphone (groq-host can reassign it)")
+	cmd.Flags().StringVar(&s.Serf.Theme, "theme", s.Serf.Theme, "theme this node records for")
 
-	sys32 = cmd.Flags().Bool("sys32", true, "for windows x64 install (see docs/install.md)")
+	cmd.Flags().StringVar(&s.HTTP.Addr, "http-bind", s.HTTP.Addr, "addr bind for the http server")
+	cmd.Flags().StringVar(&s.HTTP.CertFile, "http-cert", s.HTTP.CertFile, "tls certificate of the http server (groq-host mkcert)")
+	cmd.Flags().StringVar(&s.HTTP.KeyFile, "http-key", s.HTTP.KeyFile, "tls key of the http server")
+	cmd.Flags().StringVar(&s.HTTP.ClientCA, "http-client-ca", s.HTTP.ClientCA, "authenticate control requests with client certificates signed by this ca")
+	cmd.Flags().StringVar(&s.HTTP.TokensFile, "http-tokens", s.HTTP.TokensFile, "authenticate control requests with the bearer tokens of this file")
+
+	cmd.Flags().BoolVar(&s.Encoder.Sys32, "sys32", s.Encoder.Sys32, "for windows x64 install (see docs/install.md)")
+	bindInputFlags(cmd, s)
 
 	loop = cmd.Flags().Bool("loop", true, "disable to only serf gossip (and combine with \"\" master)")
 
 	return cmd
 }
+
+// waitShutdown waits for done, the sampler flushing its partial chunk, until
+// a second signal. It is false when the shutdown was forced.
+func waitShutdown(quit <-chan os.Signal, done <-chan struct{}) bool {
+	slog.Info("shutting down, signal again to force")
+	select {
+	case <-done:
+		return true
+	case <-quit:
+		slog.Warn("forced shutdown")
+		return false
+	}
+}
+
+// newSampler builds the sampler described by the sampler and encoder
+// sections.
+func newSampler(s *settings) (sampler.Sampler, error) {
+	encoderOpts := []sampler.EncoderOption{
+		sampler.EncoderOptionRoot(s.Sampler.Root),
+	}
+	if len(s.Encoder.Recipients) > 0 {
+		recipients, err := age.ParseRecipients(strings.NewReader(strings.Join(s.Encoder.Recipients, "\n")))
+		if err != nil {
+			return nil, fmt.Errorf("encoder.recipients: %w", err)
+		}
+		encoderOpts = append(encoderOpts, sampler.EncoderOptionRecipients(recipients...))
+	}
+	if s.Encoder.Sys32 {
+		encoderOpts = append(encoderOpts, sampler.NewSys32Opt())
+	} else {
+		encoderOpts = append(encoderOpts, sampler.EncoderOptionPath(s.Encoder.FFmpeg))
+	}
+	// the config is validated
+	policy, _ := sampler.ParsePolicy(s.Sampler.Backpressure)
+	opts := []sampler.Option{
+		sampler.OptionEncoder(encoderOpts...),
+		sampler.OptionBackpressure(policy),
+		sampler.OptionEncodeWorkers(s.Encoder.Workers),
+		sampler.OptionDevice(s.Sampler.Device),
+		sampler.OptionCaptureRate(float64(s.Sampler.CaptureRate)),
+		sampler.OptionChannels(s.Sampler.Channels),
+		sampler.OptionChannelActors(s.Sampler.ChannelActors),
+		sampler.OptionSilenceAfter(time.Duration(s.Sampler.SilenceAfter)),
+	}
+	if s.Sampler.SplitChannels {
+		opts = append(opts, sampler.OptionSplitChannels())
+	}
+	return sampler.New(
+		float64(s.Sampler.Rate),
+		time.Duration(s.Sampler.Split),
+		opts...), nil
+}
+
+// bindInputFlags binds the input device flags shared by record and serve.
+func bindInputFlags(cmd *cobra.Command, s *settings) {
+	cmd.Flags().StringVar(&s.Sampler.Device, "device", s.Sampler.Device, "index or name of the input device (groq devices list)")
+	cmd.Flags().IntVar(&s.Sampler.CaptureRate, "capture-rate", s.Sampler.CaptureRate, "input sample rate, the device default rate when 0")
+	cmd.Flags().IntVar(&s.Sampler.Channels, "channels", s.Sampler.Channels, "input channels to capture")
+	cmd.Flags().BoolVar(&s.Sampler.SplitChannels, "split-channels", s.Sampler.SplitChannels, "write a chunk per channel instead of their downmix")
+	cmd.Flags().StringSliceVar(&s.Sampler.ChannelActors, "channel-actors", s.Sampler.ChannelActors, "actors speaking into the split channels, in channel order")
+	cmd.Flags().StringVar(&s.Sampler.Backpressure, "backpressure", s.Sampler.Backpressure, "when the encoder falls behind: block, drop-oldest or spill")
+	cmd.Flags().IntVar(&s.Encoder.Workers, "encode-workers", s.Encoder.Workers, "chunks encoded at once")
+	cmd.Flags().StringSliceVar(&s.Encoder.Recipients, "recipients", s.Encoder.Recipients, "age recipients the chunks are encrypted to (age-keygen -y)")
+}
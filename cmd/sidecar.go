@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -12,57 +11,29 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"path"
 	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
 
+	"filippo.io/age"
 	"github.com/coder/websocket"
 	"github.com/fsnotify/fsnotify"
+	"github.com/malikbenkirane/groq-whisper/internal/retention"
+	"github.com/malikbenkirane/groq-whisper/internal/sampler"
+	"github.com/malikbenkirane/groq-whisper/internal/transcript"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
 
-func groqKey(file string) (string, error) {
-	var b bytes.Buffer
-	f, err := os.Open(file)
-	if err != nil {
-		return "", fmt.Errorf("open %q: %w", file, err)
-	}
-	if _, err = io.Copy(&b, f); err != nil {
-		return "", fmt.Errorf("copy key: %w", err)
-	}
-	return strings.TrimSpace(b.String()), nil
-}
-
 type groqClient struct {
-	key   string
-	log   *zap.Logger
-	tx    groqTx
-	lang  string // iso-693-1
-	start time.Time
-	root  string
-}
-
-func (gc groqClient) file() (io.WriteCloser, error) {
-	p := gc.start.Format("2006-01-02_15-04-05.000.txt")
-	base := path.Join(gc.root, "whisper-v3-tx")
-	_, err := os.Stat(base)
-	if err != nil && !errors.Is(err, os.ErrNotExist) {
-		return nil, fmt.Errorf("stat %q: %w", base, err)
-	}
-	if err != nil { // error is os.ErrNotExist
-		if err := os.MkdirAll(base, 0700); err != nil {
-			return nil, fmt.Errorf("mkdir base %q: %w", base, err)
-		}
-	}
-	p = path.Join(base, p)
-	f, err := os.OpenFile(p, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
-	if err != nil {
-		return nil, fmt.Errorf("open file %q: %w", p, err)
-	}
-	return f, nil
+	key  string
+	log  *zap.Logger
+	tx   groqTx
+	lang string // iso-693-1
+	url  string
+	// identities decrypt the sealed chunks.
+	identities []age.Identity
 }
 
 type groqTx struct {
@@ -74,8 +45,6 @@ type groqX struct {
 	Id string `json:"id"`
 }
 
-const gtxUrl = "https://api.groq.com/openai/v1/audio/transcriptions"
-
 func (gc groqClient) newRequest(audio, model string) (req *http.Request, err error) {
 	gc.log = gc.log.Named("gc request builder")
 	defer func() {
@@ -85,7 +54,8 @@ func (gc groqClient) newRequest(audio, model string) (req *http.Request, err err
 	}()
 	var body bytes.Buffer
 	writer := multipart.NewWriter(&body)
-	base := filepath.Base(audio)
+	// sealed chunks are uploaded decrypted
+	base := strings.TrimSuffix(filepath.Base(audio), ".age")
 	gc.log.Debug("create form file", zap.String("base", base))
 	part, err := writer.CreateFormFile("file", base)
 	if err != nil {
@@ -96,9 +66,9 @@ func (gc groqClient) newRequest(audio, model string) (req *http.Request, err err
 	if err != nil {
 		return nil, fmt.Errorf("os stat %q: %w", audio, err)
 	}
-	f, err := os.Open(audio)
+	f, err := sampler.OpenChunk(audio, gc.identities...)
 	if err != nil {
-		return nil, fmt.Errorf("open %q: %w", audio, err)
+		return nil, err
 	}
 	defer func() {
 		err = f.Close()
@@ -120,8 +90,8 @@ func (gc groqClient) newRequest(audio, model string) (req *http.Request, err err
 	if err := writer.Close(); err != nil {
 		return nil, fmt.Errorf("multipart writer close: %w", err)
 	}
-	gc.log.Debug("prepare post request", zap.String("url", gtxUrl))
-	req, err = http.NewRequest(http.MethodPost, gtxUrl, &body)
+	gc.log.Debug("prepare post request", zap.String("url", gc.url))
+	req, err = http.NewRequest(http.MethodPost, gc.url, &body)
 	if err != nil {
 		return nil, fmt.Errorf("http new request: %w", err)
 	}
@@ -160,13 +130,23 @@ func (gc *groqClient) post(audio, model string) (err error) {
 	return nil
 }
 
-func newCommandSidecar() (*cobra.Command, error) {
+const (
+	// sidecarDrainIdle is how long the sidecar waits for new samples once
+	// signaled, the recorders flush their partial chunk meanwhile.
+	sidecarDrainIdle = 5 * time.Second
+	// sidecarDrainTimeout bounds the pending transcriptions.
+	sidecarDrainTimeout = time.Minute
+)
+
+func newCommandSidecar(s *settings) *cobra.Command {
 	var dry, debug *bool
-	var samplesDir *string
 	cmd := &cobra.Command{
 		Use:     "sidecar",
 		Aliases: []string{"watch", "w", "s"},
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			if err := s.load(cmd); err != nil {
+				return err
+			}
 			log := newLogger(*debug)
 
 			quit := make(chan os.Signal, 1)
@@ -180,29 +160,55 @@ func newCommandSidecar() (*cobra.Command, error) {
 
 			var gc groqClient
 			{
-				key, err := groqKey("key.txt")
+				key, err := groqKey(s)
 				if err != nil {
 					return fmt.Errorf("groq key: %w", err)
 				}
 
 				gc = groqClient{
-					key:   key,
-					log:   log,
-					lang:  "fr",
-					start: time.Now(),
-					root:  *samplesDir,
+					key:  key,
+					log:  log,
+					lang: s.Groq.Language,
+					url:  s.Groq.URL,
+				}
+				if s.Sidecar.IdentityFile != "" {
+					if gc.identities, err = readIdentities(s.Sidecar.IdentityFile); err != nil {
+						return err
+					}
 				}
 				log.Debug("new client",
-					zap.String("lang", gc.lang), zap.String("url", gtxUrl))
+					zap.String("lang", gc.lang), zap.String("url", gc.url))
 			}
 
-			txOut, err := gc.file()
-			if err != nil {
-				return fmt.Errorf("tx out: %w", err)
+			var host *hostClient
+			if sc := s.Sidecar; sc.Host != "" {
+				host, err = newHostClient(sc.Host, sc.Session, sc.Actor, sc.Node, sc.HostCA)
+				if err != nil {
+					return fmt.Errorf("host client: %w", err)
+				}
+				log.Info("forwarding transcripts to host",
+					zap.String("host", sc.Host), zap.Int("session", sc.Session),
+					zap.String("actor", sc.Actor), zap.String("node", sc.Node))
+			}
+
+			var bound *boundActors
+			if api := s.Sidecar.NodeAPI; api != "" && host != nil {
+				token := ""
+				bound = &boundActors{
+					nc:  nodeClient{addr: &api, ca: &s.HTTP.CertFile, token: &token},
+					log: log,
+				}
 			}
+
+			// the config is validated
+			format, _ := transcript.ParseLineFormat(s.Sidecar.Format)
+			var sink transcript.Sink = transcript.NewFileSink(
+				filepath.Join(s.Sampler.Root, retention.TranscriptsDir), format,
+				transcript.SinkOptionRotateEvery(time.Duration(s.Sidecar.RotateEvery)),
+				transcript.SinkOptionRotateSize(int64(s.Sidecar.RotateSize)))
 			defer func() {
-				if err := txOut.Close(); err != nil {
-					slog.Warn("unable to close txOut", "err", err)
+				if err := sink.Close(); err != nil {
+					slog.Warn("unable to close the transcript sink", "err", err)
 				}
 			}()
 
@@ -218,21 +224,38 @@ func newCommandSidecar() (*cobra.Command, error) {
 			defer close(tx)
 			ctx, cancel := context.WithCancel(cmd.Context())
 			go serve(ctx, tx)
-			go func(ctx context.Context) {
+			stop := make(chan struct{})
+			done := make(chan struct{})
+			go func(ctx context.Context, stop <-chan struct{}) {
+				defer close(done)
 				counter := make(map[string]struct{})
+				// idle fires once no sample was written for sidecarDrainIdle
+				// after stop, the last chunks of the recorder are transcribed
+				// before.
+				var idle <-chan time.Time
 			loop:
 				for {
 					select {
+					case <-stop:
+						log.Info("transcribing pending samples")
+						stop = nil
+						idle = time.After(sidecarDrainIdle)
+					case <-idle:
+						return
+					case <-ctx.Done():
+						return
 					case event, ok := <-w.Events:
 						if !ok {
 							return
 						}
+						if idle != nil {
+							idle = time.After(sidecarDrainIdle)
+						}
 						log.Debug("fsnotify event",
 							zap.String("event", event.Op.String()),
 							zap.String("name", event.Name),
 						)
-						if event.Has(fsnotify.Write) &&
-							strings.HasSuffix(event.Name, ".flac") {
+						if sampleReady(event) {
 							if _, ok := counter[event.Name]; ok {
 								continue loop
 							}
@@ -245,15 +268,45 @@ func newCommandSidecar() (*cobra.Command, error) {
 							log.Info("found new sample",
 								zap.String("file", event.Name), zap.Int64("size", stat.Size()))
 							if !*dry {
-								if err = gc.post(event.Name, "whisper-large-v3"); err != nil {
+								if err = gc.post(event.Name, s.Groq.Model); err != nil {
 									log.Error("gc post failed", zap.Error(err))
 									continue loop
 								}
-								_, err := io.Copy(io.MultiWriter(os.Stdout, txOut), strings.NewReader(gc.tx.Text+"\n"))
-								if err != nil {
+								ts, t := sampleTime(event.Name), newTrack(event.Name, s.Sampler.ChannelActors)
+								if t.channel == 0 && bound != nil {
+									// the actor bound by groq-host wins over --actor
+									t.actor = bound.get(time.Now())
+								}
+								line := transcript.Line{
+									Time:      ts,
+									Chunk:     filepath.Base(event.Name),
+									RequestID: gc.tx.XGroq.Id,
+									Text:      gc.tx.Text,
+								}
+								if t.channel > 0 {
+									// the split channels merge into one labeled transcript
+									line.Speaker = t.label()
+								}
+								if err := (transcript.Text{}).Line(os.Stdout, line); err != nil {
+									slog.Warn("tx not printed", "err", err)
+								}
+								if err := sink.Write(line); err != nil {
 									slog.Error("tx not written", "err", err)
 									continue loop
 								}
+								if host != nil {
+									if err := host.postChunk(gc.tx.Text, ts, t); err != nil {
+										log.Error("host post failed", zap.Error(err))
+										continue loop
+									}
+								} else if !s.Sidecar.AckLocal {
+									continue loop
+								}
+								// the host stores the transcript, or the local one is the
+								// acknowledgment when asked
+								if err := retention.Ack(event.Name); err != nil {
+									log.Error("ack sample failed", zap.Error(err))
+								}
 							}
 						}
 					case err, ok := <-w.Errors:
@@ -263,31 +316,87 @@ func newCommandSidecar() (*cobra.Command, error) {
 						log.Error("fsnotify", zap.Error(err))
 					}
 				}
-			}(ctx)
+			}(ctx, stop)
 
-			if err = w.Add(*samplesDir); err != nil {
+			if err = w.Add(s.Sampler.Root); err != nil {
 				cancel()
 				return fmt.Errorf("fsnotify add cwd: %w", err)
 			}
+			if interval := time.Duration(s.Retention.Interval); interval > 0 {
+				go retention.Run(ctx, s.Sampler.Root, retentionPolicy(s.Retention), interval)
+			}
 
 			<-quit
+			close(stop)
+			select {
+			case <-done:
+			case <-quit:
+				log.Warn("pending samples dropped")
+			case <-time.After(sidecarDrainTimeout):
+				log.Warn("pending samples dropped", zap.Duration("after", sidecarDrainTimeout))
+			}
 			cancel()
+			// the sink is closed once the sample being transcribed is
+			// written, a line written later fails
+			select {
+			case <-done:
+			case <-quit:
+			case <-time.After(sidecarDrainTimeout):
+			}
 
 			return nil
 		},
 	}
 
-	defaultDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("user home dir: %w", err)
-	}
-
-	defaultDir = path.Join(defaultDir, "groq-whisper-samples")
 	dry = cmd.Flags().Bool("dry", false, "don't post to groq")
 	debug = cmd.Flags().Bool("debug", false, "set log level at debug")
-	samplesDir = cmd.Flags().String("samples-dir", defaultDir, "where recorded samples are processed")
+	cmd.Flags().StringVar(&s.Sampler.Root, "samples-dir", s.Sampler.Root, "where recorded samples are processed")
+	cmd.Flags().StringSliceVar(&s.Sampler.ChannelActors, "channel-actors", s.Sampler.ChannelActors, "actors speaking into the split channels, labels their transcripts")
 
-	return cmd, nil
+	cmd.Flags().StringVar(&s.Sidecar.IdentityFile, "identity", s.Sidecar.IdentityFile, "age identity file decrypting the sealed chunks (age-keygen)")
+	cmd.Flags().StringVar(&s.Sidecar.Format, "format", s.Sidecar.Format, "transcript file format: text, jsonl or markdown")
+	cmd.Flags().Var(&s.Sidecar.RotateEvery, "rotate-every", "start a new transcript file every period (e.g. 1h)")
+	cmd.Flags().Var(&s.Sidecar.RotateSize, "rotate-size", "start a new transcript file above this size (e.g. 10MB)")
+
+	cmd.Flags().StringVar(&s.Groq.KeyFile, "key-file", s.Groq.KeyFile, "file holding the groq api key")
+	cmd.Flags().StringVar(&s.Groq.Model, "model", s.Groq.Model, "groq transcription model")
+	cmd.Flags().StringVar(&s.Groq.Language, "lang", s.Groq.Language, "spoken language (iso-639-1)")
+
+	cmd.Flags().StringVar(&s.Sidecar.Host, "host", s.Sidecar.Host, "groq-host url to forward transcripts to (e.g. https://192.168.117.1:50001)")
+	cmd.Flags().StringVar(&s.Sidecar.HostCA, "host-ca", s.Sidecar.HostCA, "groq-host certificate to trust (cert.pem from groq-host mkcert)")
+	cmd.Flags().IntVar(&s.Sidecar.Session, "session", s.Sidecar.Session, "groq-host session id transcripts belong to")
+	cmd.Flags().StringVar(&s.Sidecar.Actor, "actor", s.Sidecar.Actor, "actor speaking into this node's microphone")
+	cmd.Flags().StringVar(&s.Sidecar.Node, "node", s.Sidecar.Node, "name of this recorder node")
+	cmd.Flags().BoolVar(&s.Sidecar.AckLocal, "ack-local", s.Sidecar.AckLocal, "without --host, acknowledge the chunks once transcribed locally")
+	cmd.Flags().StringVar(&s.Sidecar.NodeAPI, "node-api", s.Sidecar.NodeAPI, "http api of this recorder node the bound actor is read from (e.g. http://localhost:7495), none when empty")
+
+	return cmd
+}
+
+// sampleReady tells whether the event is a chunk fully encoded.
+func sampleReady(event fsnotify.Event) bool {
+	switch {
+	case strings.HasSuffix(event.Name, ".flac"):
+		return event.Has(fsnotify.Write)
+	case strings.HasSuffix(event.Name, ".flac.age"):
+		// sealed chunks are renamed once complete
+		return event.Has(fsnotify.Create)
+	}
+	return false
+}
+
+// readIdentities parses the age identity file.
+func readIdentities(file string) ([]age.Identity, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("open %q: %w", file, err)
+	}
+	defer f.Close()
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse identities %q: %w", file, err)
+	}
+	return identities, nil
 }
 
 func serve(ctx context.Context, tx <-chan string) {
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/malikbenkirane/groq-whisper/internal/server"
+	"github.com/spf13/cobra"
+)
+
+// nodeClient talks to the http api of a recorder node.
+type nodeClient struct {
+	addr, ca, token *string
+}
+
+func (nc nodeClient) do(method, path string, body any) (*http.Response, error) {
+	client, err := newHttpClient(*nc.ca)
+	if err != nil {
+		return nil, err
+	}
+	var r io.Reader
+	if body != nil {
+		var b bytes.Buffer
+		if err := json.NewEncoder(&b).Encode(body); err != nil {
+			return nil, fmt.Errorf("json encode: %w", err)
+		}
+		r = &b
+	}
+	url := strings.TrimSuffix(*nc.addr, "/") + path
+	req, err := http.NewRequest(method, url, r)
+	if err != nil {
+		return nil, fmt.Errorf("http new request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if *nc.token != "" {
+		req.Header.Set("Authorization", "Bearer "+*nc.token)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, url, err)
+	}
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		return nil, fmt.Errorf("%s %s: %s: %s", method, url, res.Status, bytes.TrimSpace(msg))
+	}
+	return res, nil
+}
+
+func newCommandSwarm() *cobra.Command {
+	nc := nodeClient{}
+	cmd := &cobra.Command{
+		Use:   "swarm",
+		Short: "Inspect the recorders serf swarm",
+	}
+	nc.addr = cmd.PersistentFlags().String("addr", "http://localhost:7495", "recorder node http address")
+	nc.ca = cmd.PersistentFlags().String("ca", "", "certificate to trust when the node serves https")
+	nc.token = cmd.PersistentFlags().String("token", os.Getenv("GROQ_NODE_TOKEN"), "bearer token of the node control api")
+	cmd.AddCommand(
+		newCommandSwarmStatus(nc),
+		newCommandSwarmKeygen(),
+		newCommandSwarmKey(nc, "install", "Install a gossip key on every member"),
+		newCommandSwarmKey(nc, "use", "Make an installed gossip key the primary key"),
+		newCommandSwarmKey(nc, "remove", "Remove a gossip key from every member"))
+	return cmd
+}
+
+func newCommandSwarmStatus(nc nodeClient) *cobra.Command {
+	var asJson *bool
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "List the swarm members known to a recorder node",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			res, err := nc.do(http.MethodGet, "/state", nil)
+			if err != nil {
+				return err
+			}
+			defer res.Body.Close()
+			var members []server.Member
+			if err := json.NewDecoder(res.Body).Decode(&members); err != nil {
+				return fmt.Errorf("decode state: %w", err)
+			}
+			if *asJson {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(members)
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tADDR\tSTATUS\tRECORDING\tACTOR\tTHEME\tLEVELS\tALARMS\tLAST SEEN")
+			for _, m := range members {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\t%s\t%s\t%s\t%s\n",
+					m.Name, m.Addr, m.Status, m.Recording,
+					m.Tags[server.TagActor], m.Tags[server.TagTheme],
+					m.Tags[server.TagLevels], m.Tags[server.TagAlarms],
+					m.LastSeen.Format(time.DateTime))
+			}
+			return w.Flush()
+		},
+	}
+	asJson = cmd.Flags().Bool("json", false, "print the members as json")
+	return cmd
+}
+
+func newCommandSwarmKeygen() *cobra.Command {
+	return &cobra.Command{
+		Use:   "keygen",
+		Short: "Print a new gossip encryption key",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := server.NewGossipKey()
+			if err != nil {
+				return err
+			}
+			fmt.Println(key)
+			return nil
+		},
+	}
+}
+
+// newCommandSwarmKey rotates keys through the node at --addr, a rotation
+// goes install, use then remove of the previous key. The node refuses it
+// unless it authenticates control requests.
+func newCommandSwarmKey(nc nodeClient, op, short string) *cobra.Command {
+	return &cobra.Command{
+		Use:   op + " KEY",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := server.DecodeGossipKey(args[0]); err != nil {
+				return err
+			}
+			res, err := nc.do(http.MethodPost, "/keyring/"+op, struct {
+				Key string `json:"key"`
+			}{args[0]})
+			if err != nil {
+				return err
+			}
+			defer res.Body.Close()
+			var kr server.KeyResponse
+			if err := json.NewDecoder(res.Body).Decode(&kr); err != nil {
+				return fmt.Errorf("decode key response: %w", err)
+			}
+			fmt.Printf("%d/%d members answered, %d failed\n", kr.NumResp, kr.NumNodes, kr.NumErr)
+			for node, msg := range kr.Messages {
+				fmt.Printf("%s: %s\n", node, msg)
+			}
+			if kr.NumErr > 0 {
+				return fmt.Errorf("%s failed on %d members", op, kr.NumErr)
+			}
+			return nil
+		},
+	}
+}
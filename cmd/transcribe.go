@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/malikbenkirane/groq-whisper/internal/sampler"
+	"github.com/malikbenkirane/groq-whisper/internal/transcript"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// recordingExts are the recordings groq transcribe finds in directories.
+var recordingExts = []string{".wav", ".flac", ".mp3"}
+
+func newCommandTranscribe(s *settings) *cobra.Command {
+	var output, format, progressFile *string
+	var debug *bool
+	cmd := &cobra.Command{
+		Use:   "transcribe FILE|DIR...",
+		Short: "Transcribe recordings split into chunks like the sampler does",
+		Long: `Transcribe splits the recordings into chunks of sampler.split, posts them
+to groq and writes the transcript once every chunk is transcribed. The
+transcribed chunks are recorded in the progress file, an interrupted or
+failed run resumes with the chunks left.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			if err := s.load(cmd); err != nil {
+				return err
+			}
+			if !slices.Contains(transcript.Formats, *format) {
+				return fmt.Errorf("--format: %q is not one of %s", *format, strings.Join(transcript.Formats, ", "))
+			}
+			log := newLogger(*debug)
+
+			recordings, err := findRecordings(args)
+			if err != nil {
+				return err
+			}
+			key, err := groqKey(s)
+			if err != nil {
+				return fmt.Errorf("groq key: %w", err)
+			}
+			gc := groqClient{
+				key:  key,
+				log:  log,
+				lang: s.Groq.Language,
+				url:  s.Groq.URL,
+			}
+
+			p := *progressFile
+			if p == "" {
+				p = "groq-transcribe.progress.json"
+				if *output != "" {
+					p = *output + ".progress.json"
+				}
+			}
+			progress, err := transcript.OpenProgress(p)
+			if err != nil {
+				return fmt.Errorf("progress: %w", err)
+			}
+
+			e := sampler.NewEncoder(sampler.EncoderOptionPath(s.Encoder.FFmpeg))
+			if s.Encoder.Sys32 {
+				e = sampler.NewEncoder(sampler.NewSys32Opt())
+			}
+			tmp, err := os.MkdirTemp("", "groq-transcribe-")
+			if err != nil {
+				return fmt.Errorf("mkdir temp: %w", err)
+			}
+			defer os.RemoveAll(tmp)
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+			failed, err := transcribe(ctx, transcribeJob{
+				gc:         gc,
+				model:      s.Groq.Model,
+				encoder:    e,
+				rate:       s.Sampler.Rate,
+				period:     time.Duration(s.Sampler.Split),
+				dir:        tmp,
+				workers:    s.Groq.Concurrency,
+				rpm:        s.Groq.RequestsPerMinute,
+				progress:   progress,
+				recordings: recordings,
+			})
+			if err != nil {
+				return err
+			}
+			if ctx.Err() != nil {
+				return fmt.Errorf("interrupted, run again to resume from %q", p)
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d chunks failed, run again to resume from %q", failed, p)
+			}
+
+			var segments []transcript.Segment
+			for _, r := range recordings {
+				for i := 0; ; i++ {
+					seg, ok := progress.Segment(r, i)
+					if !ok {
+						break
+					}
+					segments = append(segments, seg)
+				}
+			}
+			if err := writeTranscript(cmd.OutOrStdout(), *output, *format, segments); err != nil {
+				return err
+			}
+			// the progress is kept until the transcript is written
+			return progress.Remove()
+		},
+	}
+
+	output = cmd.Flags().StringP("output", "o", "", "transcript file, stdout when empty")
+	format = cmd.Flags().String("format", "text", "transcript format: "+strings.Join(transcript.Formats, ", "))
+	progressFile = cmd.Flags().String("progress", "", "progress file (default is the output file followed by .progress.json)")
+	debug = cmd.Flags().Bool("debug", false, "set log level at debug")
+	cmd.Flags().Var(&s.Sampler.Split, "split", "duration of a chunk")
+	cmd.Flags().IntVarP(&s.Sampler.Rate, "freq", "f", s.Sampler.Rate, "sample rate of the chunks")
+	cmd.Flags().BoolVar(&s.Encoder.Sys32, "ffmpeg-sys32", s.Encoder.Sys32, "use ffmpeg from windows/sys32/groq-deps")
+
+	cmd.Flags().StringVar(&s.Groq.KeyFile, "key-file", s.Groq.KeyFile, "file holding the groq api key")
+	cmd.Flags().StringVar(&s.Groq.Model, "model", s.Groq.Model, "groq transcription model")
+	cmd.Flags().StringVar(&s.Groq.Language, "lang", s.Groq.Language, "spoken language (iso-639-1)")
+	cmd.Flags().IntVar(&s.Groq.Concurrency, "concurrency", s.Groq.Concurrency, "chunks posted at once")
+	cmd.Flags().IntVar(&s.Groq.RequestsPerMinute, "rpm", s.Groq.RequestsPerMinute, "maximum requests per minute, unbounded when 0")
+
+	return cmd
+}
+
+// findRecordings lists the recordings of args, the files as is and the
+// recordings found in the directories, in order.
+func findRecordings(args []string) ([]string, error) {
+	var recordings []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, fmt.Errorf("stat %q: %w", arg, err)
+		}
+		if !info.IsDir() {
+			recordings = append(recordings, filepath.Clean(arg))
+			continue
+		}
+		if err := filepath.WalkDir(arg, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.Type().IsRegular() && slices.Contains(recordingExts, strings.ToLower(filepath.Ext(p))) {
+				recordings = append(recordings, p)
+			}
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("walk %q: %w", arg, err)
+		}
+	}
+	recordings = slices.Compact(recordings)
+	if len(recordings) == 0 {
+		return nil, fmt.Errorf("no recording (%s) in %s", strings.Join(recordingExts, ", "), strings.Join(args, " "))
+	}
+	return recordings, nil
+}
+
+// writeTranscript writes segments to the file output, or to stdout when
+// output is empty.
+func writeTranscript(stdout io.Writer, output, format string, segments []transcript.Segment) (err error) {
+	w := stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("create %q: %w", output, err)
+		}
+		defer func() {
+			if errClose := f.Close(); err == nil && errClose != nil {
+				err = fmt.Errorf("close %q: %w", output, errClose)
+			}
+		}()
+		w = f
+	}
+	if err := transcript.Write(w, format, segments); err != nil {
+		return fmt.Errorf("write transcript: %w", err)
+	}
+	return nil
+}
+
+type transcribeJob struct {
+	gc         groqClient
+	model      string
+	encoder    sampler.Encoder
+	rate       int
+	period     time.Duration
+	dir        string
+	workers    int
+	rpm        int
+	progress   *transcript.Progress
+	recordings []string
+}
+
+type transcribePiece struct {
+	recording string
+	index     int
+	sampler.Piece
+}
+
+// transcribe posts the chunks of the recordings not transcribed yet until
+// ctx is done, the recordings are split as the workers post. It returns the
+// number of chunks that failed.
+func transcribe(ctx context.Context, j transcribeJob) (failed int, err error) {
+	var limit <-chan time.Time
+	if j.rpm > 0 {
+		t := time.NewTicker(time.Minute / time.Duration(j.rpm))
+		defer t.Stop()
+		limit = t.C
+	}
+	pieces := make(chan transcribePiece)
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+		n  int
+	)
+	for range j.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range pieces {
+				if limit != nil {
+					select {
+					case <-limit:
+					case <-ctx.Done():
+						continue
+					}
+				}
+				// a copy per request, post keeps the response
+				gc := j.gc
+				err := gc.post(p.Path, j.model)
+				if err == nil {
+					err = j.progress.Add(p.recording, p.index, transcript.Segment{
+						File:  p.recording,
+						Start: p.Start,
+						End:   p.End,
+						Text:  strings.TrimSpace(gc.tx.Text),
+					})
+				}
+				if err != nil {
+					j.gc.log.Error("chunk not transcribed", zap.String("recording", p.recording),
+						zap.Int("chunk", p.index), zap.Error(err))
+					mu.Lock()
+					n++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	defer func() {
+		close(pieces)
+		wg.Wait()
+		failed = n
+	}()
+
+	for i, r := range j.recordings {
+		info, err := os.Stat(r)
+		if err != nil {
+			return 0, fmt.Errorf("stat %q: %w", r, err)
+		}
+		j.progress.Begin(r, info, j.period)
+		dir := filepath.Join(j.dir, fmt.Sprint(i))
+		if err := os.Mkdir(dir, 0700); err != nil {
+			return 0, fmt.Errorf("mkdir %q: %w", dir, err)
+		}
+		split, err := j.encoder.Split(r, dir, j.rate, j.period)
+		if err != nil {
+			return 0, fmt.Errorf("split %q: %w", r, err)
+		}
+		j.gc.log.Info("recording split", zap.String("recording", r), zap.Int("chunks", len(split)))
+		for k, piece := range split {
+			if _, ok := j.progress.Segment(r, k); ok {
+				continue
+			}
+			select {
+			case pieces <- transcribePiece{recording: r, index: k, Piece: piece}:
+			case <-ctx.Done():
+				return n, nil
+			}
+		}
+	}
+	return n, nil
+}
@@ -2,28 +2,63 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/malikbenkirane/groq-whisper/host/cmd/actor"
+	"github.com/malikbenkirane/groq-whisper/host/cmd/node"
+	"github.com/malikbenkirane/groq-whisper/host/cmd/search"
 	"github.com/malikbenkirane/groq-whisper/host/cmd/session"
 	"github.com/malikbenkirane/groq-whisper/host/cmd/theme"
+	"github.com/malikbenkirane/groq-whisper/host/internal/adapter/state/postgres"
 	"github.com/malikbenkirane/groq-whisper/host/internal/adapter/state/sqlite"
+	"github.com/malikbenkirane/groq-whisper/host/internal/repo"
 	"github.com/spf13/cobra"
 )
 
 func NewCLI() (*cobra.Command, error) {
+	var db *string
+	t := &theatre{}
 	cmd := &cobra.Command{
 		Use: "groq-host",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) (err error) {
+			t.Theatre, err = openTheatre(*db)
+			if err != nil {
+				return fmt.Errorf("open theatre %q: %w", *db, err)
+			}
+			return nil
+		},
 	}
+	db = cmd.PersistentFlags().String("db", "state.db",
+		"sqlite database file or postgres:// DSN")
 
-	a, err := sqlite.New()
-	if err != nil {
-		return nil, fmt.Errorf("sqlite new: %w", err)
-	}
 	cmd.AddCommand(
 		newCommandMkcert(),
-		newCommandServe(a),
-		theme.NewCommand(a),
-		actor.NewCommand(a),
-		session.NewCommand(a))
+		newCommandServe(t),
+		theme.NewCommand(t),
+		actor.NewCommand(t),
+		session.NewCommand(t),
+		search.NewCommand(t),
+		node.NewCommand(t))
 	return cmd, nil
 }
+
+// theatre lets commands be built before the --db flag is parsed, the
+// underlying repo is opened in PersistentPreRunE.
+type theatre struct {
+	repo.Theatre
+}
+
+func openTheatre(dsn string) (repo.Theatre, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		a, err := postgres.New(postgres.OptionDSN(dsn))
+		if err != nil {
+			return nil, fmt.Errorf("postgres new: %w", err)
+		}
+		return a, nil
+	}
+	a, err := sqlite.New(sqlite.OptionPath(strings.TrimPrefix(dsn, "sqlite://")))
+	if err != nil {
+		return nil, fmt.Errorf("sqlite new: %w", err)
+	}
+	return a, nil
+}
@@ -1,17 +1,34 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/malikbenkirane/groq-whisper/host/pkg/sec"
 	"github.com/spf13/cobra"
 )
 
 func newCommandMkcert() *cobra.Command {
-	return &cobra.Command{
+	var certOut, keyOut *string
+	var validFor *time.Duration
+	var client *bool
+	cmd := &cobra.Command{
 		Use:  "mkcert HOSTS",
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			certifier := sec.New(args[0])
+			opts := []sec.CertifierOption{
+				sec.OptionOut(*certOut, *keyOut),
+				sec.OptionValidFor(*validFor),
+			}
+			if *client {
+				opts = append(opts, sec.OptionClientAuth())
+			}
+			certifier := sec.New(args[0], opts...)
 			return certifier.NewCertificate()
 		},
 	}
+	certOut = cmd.Flags().String("cert", "cert.pem", "certificate output")
+	keyOut = cmd.Flags().String("key", "cert.key", "private key output")
+	validFor = cmd.Flags().Duration("valid-for", 13*24*time.Hour, "certificate validity")
+	client = cmd.Flags().Bool("client", false, "also allow client authentication (recorder mTLS)")
+	return cmd
 }
@@ -0,0 +1,45 @@
+package node
+
+import (
+	"fmt"
+
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/actor"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/node"
+	"github.com/malikbenkirane/groq-whisper/host/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+// newCommandAssign only records the binding, a running host pushes it to the
+// node on its next reconcile.
+func newCommandAssign(r repo.Theatre) *cobra.Command {
+	return &cobra.Command{
+		Use:  "assign NODE ACTOR",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			actors, err := r.Actors()
+			if err != nil {
+				return fmt.Errorf("repo actors: %w", err)
+			}
+			if _, ok := actors[args[1]]; !ok {
+				return fmt.Errorf("unknown actor %q", args[1])
+			}
+			if err := r.BindNode(node.Name(args[0]), actor.Name(args[1])); err != nil {
+				return fmt.Errorf("repo bind node: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+func newCommandUnassign(r repo.Theatre) *cobra.Command {
+	return &cobra.Command{
+		Use:  "unassign NODE",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := r.UnbindNode(node.Name(args[0])); err != nil {
+				return fmt.Errorf("repo unbind node: %w", err)
+			}
+			return nil
+		},
+	}
+}
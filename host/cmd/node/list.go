@@ -0,0 +1,37 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/malikbenkirane/groq-whisper/host/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+func newCommandList(r repo.Theatre) *cobra.Command {
+	return &cobra.Command{
+		Use: "list",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bindings, err := r.NodeBindings()
+			if err != nil {
+				return fmt.Errorf("repo node bindings: %w", err)
+			}
+			j := make([]bindingJson, 0, len(bindings))
+			for name, actor := range bindings {
+				j = append(j, bindingJson{string(name), string(actor)})
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(j); err != nil {
+				return fmt.Errorf("encode binding json: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+type bindingJson struct {
+	Node  string
+	Actor string
+}
@@ -0,0 +1,18 @@
+package node
+
+import (
+	"github.com/malikbenkirane/groq-whisper/host/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+func NewCommand(r repo.Theatre) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "node",
+		Short: "Bind recorder nodes to actors",
+	}
+	cmd.AddCommand(
+		newCommandList(r),
+		newCommandAssign(r),
+		newCommandUnassign(r))
+	return cmd
+}
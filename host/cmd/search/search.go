@@ -0,0 +1,59 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/actor"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/search"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/theme"
+	"github.com/malikbenkirane/groq-whisper/host/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+func NewCommand(r repo.Theatre) *cobra.Command {
+	var themeName, actorName, from, to *string
+	var limit *int
+	cmd := &cobra.Command{
+		Use:  "search QUERY...",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			q := search.Query{
+				Text:  strings.Join(args, " "),
+				Theme: theme.Name(*themeName),
+				Actor: actor.Name(*actorName),
+				Limit: *limit,
+			}
+			if *from != "" {
+				if q.From, err = search.ParseTime(*from); err != nil {
+					return fmt.Errorf("--from: %w", err)
+				}
+			}
+			if *to != "" {
+				if q.To, err = search.ParseTime(*to); err != nil {
+					return fmt.Errorf("--to: %w", err)
+				}
+			}
+			hits, err := r.Search(q)
+			if err != nil {
+				return fmt.Errorf("%w: %w", repo.ErrSearch, err)
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			for _, hit := range hits {
+				if err := encoder.Encode(hit.Json()); err != nil {
+					return fmt.Errorf("json encode: %w", err)
+				}
+			}
+			return nil
+		},
+	}
+	themeName = cmd.Flags().String("theme", "", "only search sessions of this theme")
+	actorName = cmd.Flags().String("actor", "", "only search sessions this actor took part in")
+	from = cmd.Flags().String("from", "", "earliest chunk timestamp (RFC 3339 or date)")
+	to = cmd.Flags().String("to", "", "latest chunk timestamp (RFC 3339 or date)")
+	limit = cmd.Flags().Int("limit", search.DefaultLimit, "maximum number of hits")
+	return cmd
+}
@@ -10,12 +10,21 @@ import (
 
 	"github.com/malikbenkirane/groq-whisper/host/internal/adapter/live/wss"
 	"github.com/malikbenkirane/groq-whisper/host/internal/adapter/rest/https"
+	"github.com/malikbenkirane/groq-whisper/host/internal/adapter/swarm/gossip"
 	"github.com/malikbenkirane/groq-whisper/host/internal/repo"
 	"github.com/spf13/cobra"
 )
 
 func newCommandServe(r repo.Theatre) *cobra.Command {
-	return &cobra.Command{
+	var (
+		serfName *string
+		serfPort *int
+		serfJoin *[]string
+		serfKey  *string
+		keyring  *string
+		plain    *bool
+	)
+	cmd := &cobra.Command{
 		Use: "serve",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			quit := make(chan os.Signal, 1)
@@ -23,15 +32,42 @@ func newCommandServe(r repo.Theatre) *cobra.Command {
 			ctx, cancel := context.WithCancel(cmd.Context())
 			defer cancel()
 
+			// errChan is never closed, the servers may still fail while
+			// the command returns
 			errChan := make(chan error)
-			defer close(errChan)
 			go func() {
 				for err := range errChan {
 					slog.Error("server failed", "err", err)
 				}
 			}()
 
-			s, err := https.New(r)
+			gossipOpts := []gossip.Option{
+				gossip.OptionName(*serfName),
+				gossip.OptionPort(*serfPort),
+				gossip.OptionJoin(*serfJoin...),
+				gossip.OptionKeyringFile(*keyring),
+			}
+			if *serfKey != "" {
+				key, err := gossip.DecodeKey(*serfKey)
+				if err != nil {
+					return fmt.Errorf("--serf-key: %w", err)
+				}
+				gossipOpts = append(gossipOpts, gossip.OptionKey(key))
+			}
+			if *plain {
+				gossipOpts = append(gossipOpts, gossip.OptionAllowPlaintext())
+			}
+			g, err := gossip.New(r, gossipOpts...)
+			if err != nil {
+				return fmt.Errorf("new gossip swarm: %w", err)
+			}
+			go func() {
+				if err := g.Serve(ctx); err != nil {
+					errChan <- err
+				}
+			}()
+
+			s, err := https.New(r, https.OptionSwarm(g))
 			if err != nil {
 				return fmt.Errorf("new https server: %w", err)
 			}
@@ -48,4 +84,11 @@ func newCommandServe(r repo.Theatre) *cobra.Command {
 			return nil
 		},
 	}
+	serfName = cmd.Flags().String("serf-name", "groq-host", "serf node name of the host")
+	serfPort = cmd.Flags().Int("serf-port", 7496, "serf bind port")
+	serfJoin = cmd.Flags().StringSlice("serf-join", nil, "recorder serf addresses to join")
+	serfKey = cmd.Flags().String("serf-key", os.Getenv("GROQ_SERF_KEY"), "base64 gossip encryption key shared with the recorders")
+	keyring = cmd.Flags().String("serf-keyring", "", "file persisting the gossip keys across rotations")
+	plain = cmd.Flags().Bool("serf-plaintext", false, "allow unencrypted gossip")
+	return cmd
 }
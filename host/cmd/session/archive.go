@@ -0,0 +1,53 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/malikbenkirane/groq-whisper/host/internal/archive"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/session"
+	"github.com/malikbenkirane/groq-whisper/host/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+func newCommandArchive(r repo.Theatre) *cobra.Command {
+	var output *string
+	var audio *[]string
+	cmd := &cobra.Command{
+		Use:   "archive ID",
+		Short: "Bundle a session into a tar.gz file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			id, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("session id: %w", err)
+			}
+			name := *output
+			if name == "" {
+				name = fmt.Sprintf("session-%d.tar.gz", id)
+			}
+			f, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+			if err != nil {
+				return fmt.Errorf("create archive: %w", err)
+			}
+			defer func() {
+				if errClose := f.Close(); err == nil && errClose != nil {
+					err = fmt.Errorf("close archive: %w", errClose)
+				}
+				if err != nil {
+					os.Remove(name)
+				}
+			}()
+			if err := archive.Export(f, r, session.Id(id), *audio, time.Now()); err != nil {
+				return fmt.Errorf("%w: %w", repo.ErrSession, err)
+			}
+			fmt.Println(name)
+			return nil
+		},
+	}
+	output = cmd.Flags().StringP("output", "o", "", "archive file (default session-ID.tar.gz)")
+	audio = cmd.Flags().StringArray("audio", nil, "recorder directory to bundle the chunks of (repeatable)")
+	return cmd
+}
@@ -26,9 +26,23 @@ func newCommandCurrent(r repo.Theatre) *cobra.Command {
 					Site: string(actor.Site),
 				}
 			}
+			tx, err := r.Transcript(s.ID)
+			if err != nil {
+				return fmt.Errorf("%w: %w", repo.ErrTranscript, err)
+			}
+			chunks := make([]chunkJson, len(tx))
+			for i, c := range tx {
+				chunks[i] = chunkJson{
+					Text:  c.Text,
+					Ts:    c.Timestamp.Format("2006-01-02T15:04:05.000"),
+					Actor: string(c.Actor),
+					Node:  c.Node,
+				}
+			}
 			encoder := json.NewEncoder(os.Stdout)
 			encoder.SetIndent("", "  ")
 			return encoder.Encode(sessionJson{
+				Chunks: chunks,
 				Actors: actors,
 				Id:     int(s.ID),
 			})
@@ -47,4 +61,9 @@ type actorJson struct {
 	Site string
 }
 
-type chunkJson struct{}
+type chunkJson struct {
+	Text  string
+	Ts    string
+	Actor string `json:",omitempty"`
+	Node  string `json:",omitempty"`
+}
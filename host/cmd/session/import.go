@@ -0,0 +1,35 @@
+package session
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/malikbenkirane/groq-whisper/host/internal/archive"
+	"github.com/malikbenkirane/groq-whisper/host/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+func newCommandImport(r repo.Theatre) *cobra.Command {
+	var audioDir *string
+	cmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Restore a session archive under a new id",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("open archive: %w", err)
+			}
+			defer f.Close()
+			imported, err := archive.Import(f, r, *audioDir)
+			if err != nil {
+				return fmt.Errorf("%w: %w", repo.ErrImportSession, err)
+			}
+			fmt.Printf("session %d imported as %d (theme %s, %d chunks, %d audio files)\n",
+				imported.From, imported.ID, imported.Theme, imported.Chunks, len(imported.Audio))
+			return nil
+		},
+	}
+	audioDir = cmd.Flags().String("audio-dir", "", "directory to extract the audio chunks to, skipped when empty")
+	return cmd
+}
@@ -8,5 +8,7 @@ import (
 func NewCommand(r repo.Theatre) *cobra.Command {
 	cmd := &cobra.Command{Use: "session"}
 	cmd.AddCommand(newCommandCurrent(r))
+	cmd.AddCommand(newCommandArchive(r))
+	cmd.AddCommand(newCommandImport(r))
 	return cmd
 }
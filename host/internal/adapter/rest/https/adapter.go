@@ -21,10 +21,18 @@ func New(r repo.Theatre, opts ...Option) (Adapter, error) {
 	mux.Handle("GET /themes", wrap(a.handleGetThemes()))
 	mux.Handle("GET /actors", wrap(a.handleGetActors()))
 	mux.Handle("GET /actors/{theme}", wrap(a.handleGetActorsTheme()))
+	mux.Handle("GET /session/{theme}", wrap(a.handleGetSession()))
 	mux.Handle("POST /session/{theme}", wrap(a.handlePostSession()))
 	mux.Handle("DELETE /session/{theme}", wrap(a.handleDeleteSession()))
 	mux.Handle("POST /lock/actor/{theme}/{actor}", wrap(a.handlePostLockActor()))
 	mux.Handle("DELETE /lock/actor/{theme}/{actor}", wrap(a.handleDeleteLockActor()))
+	mux.Handle("POST /transcript/{session}", wrap(a.handlePostTranscript()))
+	mux.Handle("GET /transcript/{session}", wrap(a.handleGetTranscript()))
+	mux.Handle("GET /transcript/{session}/export", wrap(a.handleGetTranscriptExport()))
+	mux.Handle("GET /search", wrap(a.handleGetSearch()))
+	mux.Handle("GET /nodes", wrap(a.handleGetNodes()))
+	mux.Handle("PUT /nodes/{node}/actor/{actor}", wrap(a.handlePutNodeActor()))
+	mux.Handle("DELETE /nodes/{node}/actor", wrap(a.handleDeleteNodeActor()))
 	return a, nil
 }
 
@@ -34,6 +42,8 @@ type Adapter interface {
 
 type Config struct {
 	addr, certFile, keyFile string
+
+	swarm repo.Swarm
 }
 
 type Option func(Config) Config
@@ -67,6 +77,15 @@ func OptionKeyFile(path string) Option {
 	}
 }
 
+// OptionSwarm enables the /nodes endpoints and starts or stops the
+// recorders along with the sessions.
+func OptionSwarm(s repo.Swarm) Option {
+	return func(c Config) Config {
+		c.swarm = s
+		return c
+	}
+}
+
 type adapter struct {
 	config Config
 	mux    *http.ServeMux
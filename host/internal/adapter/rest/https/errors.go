@@ -15,6 +15,7 @@ const (
 	errExpectedContentTypeJSON
 	errBadRequest
 	errStrconvSession
+	errSwarmDisabled
 	errMax
 )
 
@@ -19,12 +19,13 @@ func _() {
 	_ = x[errExpectedContentTypeJSON-8]
 	_ = x[errBadRequest-9]
 	_ = x[errStrconvSession-10]
-	_ = x[errMax-11]
+	_ = x[errSwarmDisabled-11]
+	_ = x[errMax-12]
 }
 
-const _errSys_name = "errUnknownerrGetThemeserrRepoThemeserrGetActorserrRepoActorserrJsonEncodeerrJsonDecodeerrDecodeTxPayloaderrExpectedContentTypeJSONerrBadRequesterrStrconvSessionerrMax"
+const _errSys_name = "errUnknownerrGetThemeserrRepoThemeserrGetActorserrRepoActorserrJsonEncodeerrJsonDecodeerrDecodeTxPayloaderrExpectedContentTypeJSONerrBadRequesterrStrconvSessionerrSwarmDisablederrMax"
 
-var _errSys_index = [...]uint8{0, 10, 22, 35, 47, 60, 73, 86, 104, 130, 143, 160, 166}
+var _errSys_index = [...]uint8{0, 10, 22, 35, 47, 60, 73, 86, 104, 130, 143, 160, 176, 182}
 
 func (i errSys) String() string {
 	idx := int(i) - 0
@@ -18,9 +18,17 @@ func wrap(handler customHandler) http.HandlerFunc {
 			slog.Error("HTTP sys error", "path", r.URL.Path, "err", errSys)
 		}
 		if errUser != nil || errSys != nil {
-			http.Error(w, errUser.Error(), http.StatusInternalServerError)
+			code := http.StatusInternalServerError
+			if errors.Is(errUser, errNoSession) {
+				code = http.StatusNotFound
+			}
+			http.Error(w, errUser.Error(), code)
 		}
 	}
 }
 
-var errInternalError = errors.New("internal server error")
+var (
+	errInternalError = errors.New("internal server error")
+	// errNoSession is returned stopping a theme without current session.
+	errNoSession = errors.New("no current session")
+)
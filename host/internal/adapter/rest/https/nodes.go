@@ -0,0 +1,87 @@
+package https
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/actor"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/node"
+	"github.com/malikbenkirane/groq-whisper/host/internal/repo"
+)
+
+func (a adapter) handleGetNodes() customHandler {
+	return func(w http.ResponseWriter, r *http.Request) (errUser error, errSys error) {
+		w.Header().Add("Content-Type", "application/json")
+		if a.config.swarm == nil {
+			return errSwarmDisabled, errSwarmDisabled
+		}
+		nodes, err := a.config.swarm.Nodes()
+		if err != nil {
+			return errInternalError, fmt.Errorf("%w: %w", repo.ErrNodes, err)
+		}
+		toEncode := make([]nodeJson, len(nodes))
+		for i, n := range nodes {
+			toEncode[i] = nodeJson{
+				Name:         string(n.Name),
+				Addr:         n.Addr,
+				Status:       n.Status,
+				Theme:        string(n.Theme),
+				Version:      n.Version,
+				Capabilities: n.Capabilities,
+				Binding:      string(n.Binding),
+				Levels:       n.Levels,
+				Alarms:       n.Alarms,
+			}
+			if n.Actor.Name != "" {
+				toEncode[i].Actor = &actorJson{
+					Name: string(n.Actor.Name),
+					Site: string(n.Actor.Site),
+				}
+			}
+		}
+		if err := json.NewEncoder(w).Encode(toEncode); err != nil {
+			return errInternalError, fmt.Errorf("%w: %w", errJsonEncode, err)
+		}
+		return
+	}
+}
+
+func (a adapter) handlePutNodeActor() customHandler {
+	return func(w http.ResponseWriter, r *http.Request) (errUser error, errSys error) {
+		if a.config.swarm == nil {
+			return errSwarmDisabled, errSwarmDisabled
+		}
+		nodeName := node.Name(r.PathValue("node"))
+		actorName := actor.Name(r.PathValue("actor"))
+		if err := a.config.swarm.Bind(nodeName, actorName); err != nil {
+			return errInternalError, fmt.Errorf("%w: %w", repo.ErrBindNode, err)
+		}
+		return
+	}
+}
+
+func (a adapter) handleDeleteNodeActor() customHandler {
+	return func(w http.ResponseWriter, r *http.Request) (errUser error, errSys error) {
+		if a.config.swarm == nil {
+			return errSwarmDisabled, errSwarmDisabled
+		}
+		if err := a.config.swarm.Unbind(node.Name(r.PathValue("node"))); err != nil {
+			return errInternalError, fmt.Errorf("%w: %w", repo.ErrUnbindNode, err)
+		}
+		return
+	}
+}
+
+type nodeJson struct {
+	Name         string     `json:"name"`
+	Addr         string     `json:"addr"`
+	Status       string     `json:"status"`
+	Theme        string     `json:"theme,omitempty"`
+	Version      string     `json:"version,omitempty"`
+	Capabilities []string   `json:"capabilities"`
+	Actor        *actorJson `json:"actor"`
+	Binding      string     `json:"binding,omitempty"`
+	Levels       []int      `json:"levels,omitempty"`
+	Alarms       []string   `json:"alarms,omitempty"`
+}
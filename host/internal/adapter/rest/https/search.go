@@ -0,0 +1,52 @@
+package https
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/actor"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/search"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/theme"
+	"github.com/malikbenkirane/groq-whisper/host/internal/repo"
+)
+
+func (a adapter) handleGetSearch() customHandler {
+	return func(w http.ResponseWriter, r *http.Request) (errUser error, errSys error) {
+		w.Header().Add("Content-Type", "application/json")
+		params := r.URL.Query()
+		q := search.Query{
+			Text:  params.Get("q"),
+			Theme: theme.Name(params.Get("theme")),
+			Actor: actor.Name(params.Get("actor")),
+		}
+		for _, bound := range []struct {
+			param string
+			t     *time.Time
+		}{{"from", &q.From}, {"to", &q.To}} {
+			if v := params.Get(bound.param); v != "" {
+				t, err := search.ParseTime(v)
+				if err != nil {
+					return errBadRequest, fmt.Errorf("%w: %s: %w", errBadRequest, bound.param, err)
+				}
+				*bound.t = t
+			}
+		}
+		if err := q.Validate(); err != nil {
+			return errBadRequest, fmt.Errorf("%w: %w", errBadRequest, err)
+		}
+		hits, err := a.repo.Search(q)
+		if err != nil {
+			return errInternalError, fmt.Errorf("%w: %w", repo.ErrSearch, err)
+		}
+		toEncode := make([]search.HitJson, len(hits))
+		for i, hit := range hits {
+			toEncode[i] = hit.Json()
+		}
+		if err := json.NewEncoder(w).Encode(toEncode); err != nil {
+			return errInternalError, fmt.Errorf("%w: %w", errJsonEncode, err)
+		}
+		return
+	}
+}
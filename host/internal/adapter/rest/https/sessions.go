@@ -1,28 +1,141 @@
 package https
 
 import (
+	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/node"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/session"
 	"github.com/malikbenkirane/groq-whisper/host/internal/domain/theme"
 	"github.com/malikbenkirane/groq-whisper/host/internal/repo"
 )
 
 func (a adapter) handlePostSession() customHandler {
 	return func(w http.ResponseWriter, r *http.Request) (errUser error, errSys error) {
-		if err := a.repo.StartSession(theme.Name(r.PathValue("theme")), time.Now()); err != nil {
+		themeName := theme.Name(r.PathValue("theme"))
+		if err := a.repo.StartSession(themeName, time.Now()); err != nil {
 			return errInternalError, fmt.Errorf("%w: %w", repo.ErrStartSession, err)
 		}
-		return
+		s, err := a.repo.CurrentSession(themeName)
+		if err != nil {
+			return errInternalError, fmt.Errorf("%w: %w", repo.ErrCurrentSession, err)
+		}
+		return a.record(w, themeName, s, true)
 	}
 }
 
 func (a adapter) handleDeleteSession() customHandler {
 	return func(w http.ResponseWriter, r *http.Request) (errUser error, errSys error) {
-		if err := a.repo.StopSession(theme.Name(r.PathValue("theme")), time.Now()); err != nil {
+		themeName := theme.Name(r.PathValue("theme"))
+		s, err := a.repo.CurrentSession(themeName)
+		if err != nil {
+			return errInternalError, fmt.Errorf("%w: %w", repo.ErrCurrentSession, err)
+		}
+		if s == nil {
+			return errNoSession, nil
+		}
+		if err := a.repo.StopSession(themeName, time.Now()); err != nil {
 			return errInternalError, fmt.Errorf("%w: %w", repo.ErrStopSession, err)
 		}
+		return a.record(w, themeName, s, false)
+	}
+}
+
+// record starts or stops the recorders of session s and writes their
+// acknowledgements. The session changed already, a swarm failure is
+// reported in the body rather than as a failed request.
+func (a adapter) record(w http.ResponseWriter, themeName theme.Name, s *session.Session, start bool) (errUser error, errSys error) {
+	if a.config.swarm == nil {
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	res := recordJson{Recorders: []ackJson{}}
+	if s != nil {
+		res.Session = int(s.ID)
+	}
+	acks, err := a.config.swarm.Record(themeName, session.Id(res.Session), start)
+	if err != nil {
+		err = fmt.Errorf("%w: %w", repo.ErrRecord, err)
+		slog.Error("record", "theme", themeName, "session", res.Session, "start", start, "err", err)
+		res.Err = err.Error()
+	} else {
+		res.Recorders = acksJsonOf(acks)
+	}
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		return errInternalError, fmt.Errorf("%w: %w", errJsonEncode, err)
+	}
+	return
+}
+
+func (a adapter) handleGetSession() customHandler {
+	return func(w http.ResponseWriter, r *http.Request) (errUser error, errSys error) {
+		w.Header().Add("Content-Type", "application/json")
+		themeName := theme.Name(r.PathValue("theme"))
+		s, err := a.repo.CurrentSession(themeName)
+		if err != nil {
+			return errInternalError, fmt.Errorf("%w: %w", repo.ErrCurrentSession, err)
+		}
+		status := sessionStatusJson{
+			Theme:     string(themeName),
+			Actors:    []actorJson{},
+			Recorders: []ackJson{},
+		}
+		if s != nil {
+			status.Session = new(int)
+			*status.Session = int(s.ID)
+			for _, actor := range s.Actors {
+				status.Actors = append(status.Actors, actorJson{
+					Name: string(actor.Name),
+					Site: string(actor.Site),
+				})
+			}
+		}
+		if a.config.swarm != nil {
+			status.Recorders = acksJsonOf(a.config.swarm.Acks(themeName))
+		}
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			return errInternalError, fmt.Errorf("%w: %w", errJsonEncode, err)
+		}
 		return
 	}
 }
+
+type sessionStatusJson struct {
+	Theme     string      `json:"theme"`
+	Session   *int        `json:"session"`
+	Actors    []actorJson `json:"actors"`
+	Recorders []ackJson   `json:"recorders"`
+}
+
+// recordJson is the body of POST and DELETE /session/{theme}, Err is why
+// the recorders could not be reached.
+type recordJson struct {
+	Session   int       `json:"session"`
+	Recorders []ackJson `json:"recorders"`
+	Err       string    `json:"err,omitempty"`
+}
+
+type ackJson struct {
+	Node      string    `json:"node"`
+	Session   int       `json:"session"`
+	Recording bool      `json:"recording"`
+	Since     time.Time `json:"since"`
+	Err       string    `json:"err,omitempty"`
+}
+
+func acksJsonOf(acks []node.Ack) []ackJson {
+	j := make([]ackJson, len(acks))
+	for i, ack := range acks {
+		j[i] = ackJson{
+			Node:      string(ack.Node),
+			Session:   int(ack.Session),
+			Recording: ack.Recording,
+			Since:     ack.Since,
+			Err:       ack.Err,
+		}
+	}
+	return j
+}
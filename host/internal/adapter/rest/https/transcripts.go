@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/actor"
 	"github.com/malikbenkirane/groq-whisper/host/internal/domain/session"
 	"github.com/malikbenkirane/groq-whisper/host/internal/domain/transcript"
 	"github.com/malikbenkirane/groq-whisper/host/internal/repo"
@@ -19,18 +21,16 @@ func (a adapter) handlePostTranscript() customHandler {
 				"%w: got %q", errExpectedContentTypeJSON, r.Header.Get("Content-Type"))
 		}
 
-		var s session.Id
-		{
-			intId, err := strconv.Atoi(r.PathValue("session"))
-			if err != nil {
-				return errBadRequest, fmt.Errorf("%w: %w", errStrconvSession, err)
-			}
-			s = session.Id(intId)
+		s, err := sessionId(r)
+		if err != nil {
+			return errBadRequest, err
 		}
 
 		var tx struct {
-			Tx string
-			Ts string
+			Tx    string
+			Ts    string
+			Actor string
+			Node  string
 		}
 
 		if err := json.NewDecoder(r.Body).Decode(&tx); err != nil {
@@ -45,6 +45,8 @@ func (a adapter) handlePostTranscript() customHandler {
 		if err := a.repo.SaveTranscriptChunk(transcript.Chunk{
 			Text:      tx.Tx,
 			Timestamp: t,
+			Actor:     actor.Name(tx.Actor),
+			Node:      tx.Node,
 		}, s); err != nil {
 			return errInternalError, fmt.Errorf("%w: %w", repo.ErrSaveTranscriptChunk, err)
 		}
@@ -53,4 +55,100 @@ func (a adapter) handlePostTranscript() customHandler {
 	}
 }
 
+func (a adapter) handleGetTranscript() customHandler {
+	return func(w http.ResponseWriter, r *http.Request) (errUser error, errSys error) {
+		w.Header().Add("Content-Type", "application/json")
+		s, err := sessionId(r)
+		if err != nil {
+			return errBadRequest, err
+		}
+		chunks, err := a.repo.Transcript(s)
+		if err != nil {
+			return errInternalError, fmt.Errorf("%w: %w", repo.ErrTranscript, err)
+		}
+		turns := transcript.Turns(chunks)
+		toEncode := make([]turnJson, len(turns))
+		for i, turn := range turns {
+			toEncode[i] = turnJson{
+				Actor: string(turn.Actor),
+				Node:  turn.Node,
+				Start: turn.Start.Format(iso8601),
+				End:   turn.End.Format(iso8601),
+			}
+			for _, c := range turn.Chunks {
+				toEncode[i].Chunks = append(toEncode[i].Chunks, chunkJson{
+					Text: c.Text,
+					Ts:   c.Timestamp.Format(iso8601),
+				})
+			}
+		}
+		if err := json.NewEncoder(w).Encode(toEncode); err != nil {
+			return errInternalError, fmt.Errorf("%w: %w", errJsonEncode, err)
+		}
+		return
+	}
+}
+
+// handleGetTranscriptExport renders the session transcript one speaker turn
+// per paragraph, as plain text or markdown (?format=md).
+func (a adapter) handleGetTranscriptExport() customHandler {
+	return func(w http.ResponseWriter, r *http.Request) (errUser error, errSys error) {
+		s, err := sessionId(r)
+		if err != nil {
+			return errBadRequest, err
+		}
+		format := r.URL.Query().Get("format")
+		if format != "" && format != "txt" && format != "md" {
+			return errBadRequest, fmt.Errorf("%w: unknown format %q", errBadRequest, format)
+		}
+		chunks, err := a.repo.Transcript(s)
+		if err != nil {
+			return errInternalError, fmt.Errorf("%w: %w", repo.ErrTranscript, err)
+		}
+		var b strings.Builder
+		if format == "md" {
+			w.Header().Add("Content-Type", "text/markdown; charset=utf-8")
+			fmt.Fprintf(&b, "# Session %d\n\n", s)
+		} else {
+			w.Header().Add("Content-Type", "text/plain; charset=utf-8")
+		}
+		for _, turn := range transcript.Turns(chunks) {
+			speaker := turn.Chunks[0].Speaker()
+			if speaker == "" {
+				speaker = "unknown"
+			}
+			if format == "md" {
+				fmt.Fprintf(&b, "**%s** _%s_\n\n%s\n\n", speaker, turn.Start.Format(time.TimeOnly), turn.Text())
+				continue
+			}
+			fmt.Fprintf(&b, "[%s] %s: %s\n", turn.Start.Format(time.TimeOnly), speaker, turn.Text())
+		}
+		if _, err := w.Write([]byte(b.String())); err != nil {
+			return errInternalError, fmt.Errorf("write export: %w", err)
+		}
+		return
+	}
+}
+
+func sessionId(r *http.Request) (session.Id, error) {
+	intId, err := strconv.Atoi(r.PathValue("session"))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", errStrconvSession, err)
+	}
+	return session.Id(intId), nil
+}
+
+type turnJson struct {
+	Actor  string      `json:"actor,omitempty"`
+	Node   string      `json:"node,omitempty"`
+	Start  string      `json:"start"`
+	End    string      `json:"end"`
+	Chunks []chunkJson `json:"chunks"`
+}
+
+type chunkJson struct {
+	Text string `json:"text"`
+	Ts   string `json:"ts"`
+}
+
 const iso8601 = "2006-01-02T15:04:05.000"
@@ -0,0 +1,332 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/actor"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/session"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/theme"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/transcript"
+	"github.com/malikbenkirane/groq-whisper/host/internal/repo"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+func New(opts ...Option) (repo.Theatre, error) {
+	conf := defaultConfig()
+	for _, opt := range opts {
+		conf = opt(conf)
+	}
+	db, err := sql.Open("pgx", conf.dsn)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errOpenDB, err)
+	}
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errPingDB, err)
+	}
+	if err := migrate(db); err != nil {
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+	a := adapter{
+		conf: conf,
+		db:   db,
+	}
+	return a, nil
+}
+
+type Config struct {
+	dsn string
+}
+
+type Option func(Config) Config
+
+func defaultConfig() Config {
+	return Config{
+		dsn: "postgres://localhost:5432/groq?sslmode=disable",
+	}
+}
+
+// OptionDSN sets the connection string, either as a postgres:// URL or as
+// libpq key=value pairs.
+func OptionDSN(dsn string) Option {
+	return func(c Config) Config {
+		c.dsn = dsn
+		return c
+	}
+}
+
+type adapter struct {
+	db   *sql.DB
+	conf Config
+}
+
+func (a adapter) Actors() (map[string]actor.Call, error) {
+	rows, err := a.db.Query(`SELECT name, site FROM actors`)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errSelectActors, err)
+	}
+	defer rows.Close()
+	actors := make(map[string]actor.Call)
+	for rows.Next() {
+		var row struct {
+			name, site string
+		}
+		if err := rows.Scan(&row.name, &row.site); err != nil {
+			return nil, fmt.Errorf("%w: %w", errSelectActorsScan, err)
+		}
+		actors[row.name] = actor.Call(row.site)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errSelectActorsIter, err)
+	}
+	return actors, nil
+}
+
+func (a adapter) Themes() (map[string]theme.Description, error) {
+	rows, err := a.db.Query(`SELECT name, title, category, keyword FROM themes`)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errSelectThemes, err)
+	}
+	defer rows.Close()
+	themes := make(map[string]*theme.Description)           // index themes
+	keywords := make(map[string]map[string][]theme.Keyword) // index category keywords
+	for rows.Next() {
+		var row struct {
+			name     string
+			title    sql.NullString
+			category string
+			keyword  string
+		}
+		if err := rows.Scan(&row.name, &row.title, &row.category, &row.keyword); err != nil {
+			return nil, fmt.Errorf("%w: %w", errSelectThemesScan, err)
+		}
+		if _, ok := themes[row.name]; !ok {
+			themes[row.name] = &theme.Description{
+				Name: theme.Name(row.name),
+			}
+			if row.title.Valid {
+				themes[row.name].Title = row.title.String
+			}
+			keywords[row.name] = make(map[string][]theme.Keyword)
+		}
+		keywords[row.name][row.category] = append(keywords[row.name][row.category],
+			theme.Keyword(row.keyword))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errSelectThemesIter, err)
+	}
+	for name, categories := range keywords {
+		for category, keywords := range categories {
+			cat := theme.Category{
+				Name:     category,
+				Keywords: keywords,
+			}
+			themes[name].Categories = append(themes[name].Categories, cat)
+		}
+	}
+	final := make(map[string]theme.Description, len(themes))
+	for _, theme := range themes {
+		final[string(theme.Name)] = *theme
+	}
+	return final, nil
+}
+
+func (a adapter) setLock(isLocked bool, name actor.Name, id session.Id) error {
+	if _, err := a.db.Exec(`
+INSERT INTO actors_locks (islocked, actor, session) VALUES ($1, $2, $3)
+ON CONFLICT (actor) DO UPDATE SET islocked = EXCLUDED.islocked
+	`, isLocked, string(name), int(id)); err != nil {
+		return fmt.Errorf("%w: %w", errExecSetLock, err)
+	}
+	return nil
+}
+
+func (a adapter) LockActor(name actor.Name, id session.Id) error {
+	if err := a.setLock(true, name, id); err != nil {
+		return err
+	}
+	return addSessionActor(a.db, name, id)
+}
+
+func (a adapter) UnlockActor(name actor.Name, id session.Id) error {
+	return a.setLock(false, name, id)
+}
+
+func (a adapter) IsActorLocked(name actor.Name) (*session.Id, error) {
+	row := a.db.QueryRow(`
+SELECT session FROM actors_locks WHERE actor = $1
+	`, string(name))
+	var id int
+	err := row.Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errQueryRowActorsLocks, err)
+	}
+	sid := new(session.Id)
+	*sid = session.Id(id)
+	return sid, nil
+}
+
+func (a adapter) ResetActorLocks() error {
+	res, err := a.db.Exec(`DELETE FROM actors_locks`)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errDeleteActorsLocks, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %w: %w", errDeleteActorsLocks, errReadRowsAffected, err)
+	}
+	slog.Warn("actor locks reset", "n", n)
+	return nil
+}
+
+func (a adapter) StartSession(name theme.Name, t time.Time) error {
+	if _, err := a.db.Exec(`
+INSERT INTO sessions (theme, start_at) VALUES ($1, $2)
+	`, string(name), t); err != nil {
+		return fmt.Errorf("%w: %w", errInsertSession, err)
+	}
+	return nil
+}
+
+func (a adapter) StopSession(name theme.Name, t time.Time) error {
+	if _, err := a.db.Exec(`
+UPDATE sessions SET stop_at = $1 WHERE theme = $2 AND stop_at IS NULL
+	`, t, string(name)); err != nil {
+		return fmt.Errorf("%w: %w", errUpdateSession, err)
+	}
+	return nil
+}
+
+func (a adapter) CurrentSession(name theme.Name) (*session.Session, error) {
+	row := a.db.QueryRow(`
+SELECT id FROM sessions WHERE theme = $1 AND stop_at IS NULL ORDER BY start_at DESC LIMIT 1
+	`, string(name))
+	var id int
+	err := row.Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errQueryRowCurrentSession, err)
+	}
+	actors, err := a.actors(session.Id(id))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errActorsSession, err)
+	}
+	themes, err := a.Themes()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errThemes, err)
+	}
+	return &session.Session{
+		Actors: actors,
+		Theme:  themes[string(name)],
+		ID:     session.Id(id),
+	}, nil
+}
+
+func (a adapter) actors(id session.Id) ([]actor.Description, error) {
+	rows, err := a.db.Query(`
+SELECT l.actor, a.site FROM actors_locks l JOIN actors a ON a.name = l.actor WHERE l.session = $1
+	`, int(id))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errSelectActorsLocks, err)
+	}
+	defer rows.Close()
+	actors := []actor.Description{}
+	for rows.Next() {
+		var name, site string
+		if err := rows.Scan(&name, &site); err != nil {
+			return nil, fmt.Errorf("%w: %w: %w", errSelectActorsLocks, errScan, err)
+		}
+		actors = append(actors, actor.Description{
+			Name: actor.Name(name),
+			Site: actor.Call(site),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errSelectActorsLocks, err)
+	}
+	return actors, nil
+}
+
+func (a adapter) UnlockedActors(name theme.Name) ([]actor.Description, error) {
+	actors, err := a.Actors()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errActors, err)
+	}
+	rows, err := a.db.Query(`
+SELECT actor FROM actors_locks WHERE islocked
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%w, %w", errSelectActorsLocks, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("%w: %w: %w", errSelectActorsLocks, errScan, err)
+		}
+		delete(actors, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errSelectActorsLocks, err)
+	}
+	names := make([]actor.Description, 0, len(actors))
+	for name, site := range actors {
+		names = append(names, actor.Description{Name: actor.Name(name), Site: site})
+	}
+	return names, nil
+}
+
+func (a adapter) SaveTranscriptChunk(chunk transcript.Chunk, id session.Id) error {
+	_, err := a.db.Exec(`
+INSERT INTO tx (session, text, t, actor, node) VALUES ($1, $2, $3, $4, $5)
+	`, int(id), chunk.Text, chunk.Timestamp,
+		nullString(string(chunk.Actor)), nullString(chunk.Node))
+	if err != nil {
+		return fmt.Errorf("%w: %w", errInsertTx, err)
+	}
+	return nil
+}
+
+func (a adapter) Transcript(id session.Id) ([]transcript.Chunk, error) {
+	rows, err := a.db.Query(`
+SELECT text, t, actor, node FROM tx WHERE session = $1 ORDER BY t
+	`, int(id))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errSelectTx, err)
+	}
+	defer rows.Close()
+	chunks := []transcript.Chunk{}
+	for rows.Next() {
+		var row struct {
+			text        string
+			t           time.Time
+			actor, node sql.NullString
+		}
+		if err := rows.Scan(&row.text, &row.t, &row.actor, &row.node); err != nil {
+			return nil, fmt.Errorf("%w: %w: %w", errSelectTx, errScan, err)
+		}
+		chunks = append(chunks, transcript.Chunk{
+			Text:      row.text,
+			Timestamp: row.t,
+			Actor:     actor.Name(row.actor.String),
+			Node:      row.node.String,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errSelectTx, err)
+	}
+	return chunks, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
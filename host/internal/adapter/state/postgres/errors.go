@@ -0,0 +1,47 @@
+package postgres
+
+//go:generate stringer -type=errAdapter
+type errAdapter int
+
+func (err errAdapter) Error() string {
+	return err.String()
+}
+
+const (
+	errZero errAdapter = iota
+	errOpenDB
+	errPingDB
+	errMigrate
+	errReadMigrations
+	errSelectThemes
+	errSelectThemesIter
+	errSelectThemesScan
+	errSelectActors
+	errSelectActorsIter
+	errSelectActorsScan
+	errExecSetLock
+	errQueryRowActorsLocks
+	errDeleteActorsLocks
+	errReadRowsAffected
+	errInsertSession
+	errUpdateSession
+	errQueryRowCurrentSession
+	errSelectActorsLocks
+	errScan
+	errActorsSession
+	errThemes
+	errActors
+	errInsertTx
+	errSelectTx
+	errSearchQuery
+	errSearchScan
+	errSearchIter
+	errExecBindNode
+	errDeleteNodesActors
+	errSelectNodesActors
+	errQueryRowSession
+	errSelectSessionsActors
+	errExecSessionsActors
+	errImportSession
+	errUnknown
+)
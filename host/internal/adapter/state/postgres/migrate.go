@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// migrate applies the embedded migrations that are not yet recorded in the
+// schema_migrations table, in file name order, each in its own transaction.
+func migrate(db *sql.DB) error {
+	if _, err := db.Exec(`
+CREATE TABLE IF NOT EXISTS schema_migrations (version TEXT PRIMARY KEY)
+	`); err != nil {
+		return fmt.Errorf("%w: create schema_migrations: %w", errMigrate, err)
+	}
+	names, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return fmt.Errorf("%w: %w", errReadMigrations, err)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		version := strings.TrimSuffix(strings.TrimPrefix(name, "migrations/"), ".sql")
+		var applied bool
+		if err := db.QueryRow(`
+SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)
+		`, version).Scan(&applied); err != nil {
+			return fmt.Errorf("%w: %q: %w", errMigrate, version, err)
+		}
+		if applied {
+			continue
+		}
+		stmts, err := migrations.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("%w: %q: %w", errReadMigrations, name, err)
+		}
+		if err := apply(db, version, string(stmts)); err != nil {
+			return fmt.Errorf("%w: %q: %w", errMigrate, version, err)
+		}
+		slog.Info("postgres migration applied", "version", version)
+	}
+	return nil
+}
+
+func apply(db *sql.DB, version, stmts string) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(); errRollback != nil {
+				slog.Error("postgres migration rollback", "version", version, "err", errRollback)
+			}
+		}
+	}()
+	if _, err = tx.Exec(stmts); err != nil {
+		return fmt.Errorf("exec: %w", err)
+	}
+	if _, err = tx.Exec(`
+INSERT INTO schema_migrations (version) VALUES ($1)
+	`, version); err != nil {
+		return fmt.Errorf("record version: %w", err)
+	}
+	return tx.Commit()
+}
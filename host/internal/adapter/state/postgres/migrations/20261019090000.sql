@@ -0,0 +1,35 @@
+-- Create themes table
+CREATE TABLE themes (
+		name TEXT NOT NULL,
+		title TEXT,
+		category TEXT NOT NULL,
+		keyword TEXT NOT NULL
+);
+-- Create actors table
+CREATE TABLE actors (
+		name TEXT PRIMARY KEY,
+		site TEXT NOT NULL
+);
+-- Create sessions table
+CREATE TABLE sessions (
+		id SERIAL PRIMARY KEY,
+		theme TEXT NOT NULL,
+		start_at TIMESTAMPTZ NOT NULL,
+		stop_at TIMESTAMPTZ
+);
+-- Create actors_locks table
+CREATE TABLE actors_locks (
+		id SERIAL PRIMARY KEY,
+		islocked BOOLEAN NOT NULL,
+		session INTEGER NOT NULL,
+		actor TEXT NOT NULL
+);
+-- Create tx table
+CREATE TABLE tx (
+		session INTEGER NOT NULL,
+		t TIMESTAMPTZ NOT NULL,
+		text TEXT NOT NULL
+);
+CREATE UNIQUE INDEX idx_actors_locks_name ON actors_locks(actor);
+CREATE INDEX idx_sessions_theme ON sessions(theme, start_at);
+CREATE INDEX idx_tx_session ON tx(session, t);
@@ -0,0 +1,7 @@
+-- Record the actors of each session, the locks only hold the current ones
+CREATE TABLE sessions_actors (
+		session INTEGER NOT NULL,
+		actor TEXT NOT NULL,
+		PRIMARY KEY (session, actor)
+);
+INSERT INTO sessions_actors (session, actor) SELECT session, actor FROM actors_locks;
@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/actor"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/node"
+)
+
+func (a adapter) BindNode(name node.Name, actorName actor.Name) error {
+	if _, err := a.db.Exec(`
+INSERT INTO nodes_actors (node, actor) VALUES ($1, $2)
+ON CONFLICT (node) DO UPDATE SET actor = EXCLUDED.actor
+	`, string(name), string(actorName)); err != nil {
+		return fmt.Errorf("%w: %w", errExecBindNode, err)
+	}
+	return nil
+}
+
+func (a adapter) UnbindNode(name node.Name) error {
+	if _, err := a.db.Exec(`
+DELETE FROM nodes_actors WHERE node = $1
+	`, string(name)); err != nil {
+		return fmt.Errorf("%w: %w", errDeleteNodesActors, err)
+	}
+	return nil
+}
+
+func (a adapter) NodeBindings() (map[node.Name]actor.Name, error) {
+	rows, err := a.db.Query(`SELECT node, actor FROM nodes_actors`)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errSelectNodesActors, err)
+	}
+	defer rows.Close()
+	bindings := make(map[node.Name]actor.Name)
+	for rows.Next() {
+		var n, bound string
+		if err := rows.Scan(&n, &bound); err != nil {
+			return nil, fmt.Errorf("%w: %w: %w", errSelectNodesActors, errScan, err)
+		}
+		bindings[node.Name(n)] = actor.Name(bound)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errSelectNodesActors, err)
+	}
+	return bindings, nil
+}
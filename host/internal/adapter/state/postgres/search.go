@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/search"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/session"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/theme"
+)
+
+// Search narrows candidates with ILIKE and leaves matching and highlighting
+// to the generic search.Match.
+func (a adapter) Search(q search.Query) ([]search.Hit, error) {
+	if err := q.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errSearchQuery, err)
+	}
+	terms := q.Terms()
+	patterns := make([]string, len(terms))
+	for i, t := range terms {
+		patterns[i] = "%" + t + "%"
+	}
+	var from, to sql.NullTime
+	if !q.From.IsZero() {
+		from = sql.NullTime{Time: q.From, Valid: true}
+	}
+	if !q.To.IsZero() {
+		to = sql.NullTime{Time: q.To, Valid: true}
+	}
+	rows, err := a.db.Query(`
+SELECT tx.session, s.theme, tx.t, tx.text
+FROM tx
+JOIN sessions s ON s.id = tx.session
+WHERE tx.text ILIKE ALL($1)
+AND ($2 = '' OR s.theme = $2)
+AND ($3::timestamptz IS NULL OR tx.t >= $3)
+AND ($4::timestamptz IS NULL OR tx.t <= $4)
+AND ($5 = '' OR tx.actor = $5 OR (tx.actor IS NULL AND EXISTS (
+	SELECT 1 FROM actors_locks l WHERE l.session = tx.session AND l.actor = $5
+)))
+ORDER BY tx.t DESC
+	`, patterns, string(q.Theme), from, to, string(q.Actor))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errSearchQuery, err)
+	}
+	defer rows.Close()
+	hits := []search.Hit{}
+	for rows.Next() && len(hits) < q.Max() {
+		var row struct {
+			session     int
+			theme, text string
+			t           time.Time
+		}
+		if err := rows.Scan(&row.session, &row.theme, &row.t, &row.text); err != nil {
+			return nil, fmt.Errorf("%w: %w", errSearchScan, err)
+		}
+		snippet, ok := search.Match(q, row.text)
+		if !ok {
+			continue
+		}
+		hits = append(hits, search.Hit{
+			Session:   session.Id(row.session),
+			Theme:     theme.Name(row.theme),
+			Timestamp: row.t,
+			Snippet:   snippet,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errSearchIter, err)
+	}
+	return hits, nil
+}
@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/actor"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/session"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/theme"
+)
+
+func (a adapter) Session(id session.Id) (*session.Session, error) {
+	row := a.db.QueryRow(`
+SELECT theme, start_at, stop_at FROM sessions WHERE id = $1
+	`, int(id))
+	var (
+		name  string
+		start time.Time
+		stop  sql.NullTime
+	)
+	err := row.Scan(&name, &start, &stop)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errQueryRowSession, err)
+	}
+	s := &session.Session{ID: id}
+	s.Start(start)
+	if stop.Valid {
+		s.End(stop.Time)
+	}
+	if s.Actors, err = a.sessionActors(id); err != nil {
+		return nil, fmt.Errorf("%w: %w", errActorsSession, err)
+	}
+	themes, err := a.Themes()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errThemes, err)
+	}
+	s.Theme = themes[name]
+	s.Theme.Name = theme.Name(name)
+	return s, nil
+}
+
+// sessionActors are the actors that were locked in the session, including
+// the ones locked before sessions_actors recorded them.
+func (a adapter) sessionActors(id session.Id) ([]actor.Description, error) {
+	rows, err := a.db.Query(`
+SELECT name, site FROM actors WHERE name IN (
+	SELECT actor FROM sessions_actors WHERE session = $1
+	UNION SELECT actor FROM actors_locks WHERE session = $1
+) ORDER BY name
+	`, int(id))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errSelectSessionsActors, err)
+	}
+	defer rows.Close()
+	actors := []actor.Description{}
+	for rows.Next() {
+		var name, site string
+		if err := rows.Scan(&name, &site); err != nil {
+			return nil, fmt.Errorf("%w: %w: %w", errSelectSessionsActors, errScan, err)
+		}
+		actors = append(actors, actor.Description{
+			Name: actor.Name(name),
+			Site: actor.Call(site),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errSelectSessionsActors, err)
+	}
+	return actors, nil
+}
+
+// execer is a db or a transaction.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func addSessionActor(db execer, name actor.Name, id session.Id) error {
+	if _, err := db.Exec(`
+INSERT INTO sessions_actors (session, actor) VALUES ($1, $2)
+ON CONFLICT (session, actor) DO NOTHING
+	`, int(id), string(name)); err != nil {
+		return fmt.Errorf("%w: %w", errExecSessionsActors, err)
+	}
+	return nil
+}
+
+func (a adapter) ImportSession(s session.Session) (id session.Id, err error) {
+	start, end := s.StartAt(), s.EndAt()
+	if start == nil || end == nil {
+		return 0, fmt.Errorf("%w: session %d did not end", errImportSession, s.ID)
+	}
+	tx, err := a.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%w: begin: %w", errImportSession, err)
+	}
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(); errRollback != nil {
+				slog.Error("postgres import rollback", "err", errRollback)
+			}
+		}
+	}()
+
+	var known bool
+	if err := tx.QueryRow(`
+SELECT EXISTS (SELECT 1 FROM themes WHERE name = $1)
+	`, string(s.Theme.Name)).Scan(&known); err != nil {
+		return 0, fmt.Errorf("%w: %w", errSelectThemes, err)
+	}
+	if !known {
+		if err := insertTheme(tx, s.Theme); err != nil {
+			return 0, err
+		}
+	}
+	for _, d := range s.Actors {
+		if _, err := tx.Exec(`
+INSERT INTO actors (name, site) VALUES ($1, $2) ON CONFLICT (name) DO NOTHING
+		`, string(d.Name), string(d.Site)); err != nil {
+			return 0, fmt.Errorf("%w: insert actor %q: %w", errImportSession, d.Name, err)
+		}
+	}
+
+	var n int
+	if err := tx.QueryRow(`
+INSERT INTO sessions (theme, start_at, stop_at) VALUES ($1, $2, $3) RETURNING id
+	`, string(s.Theme.Name), *start, *end).Scan(&n); err != nil {
+		return 0, fmt.Errorf("%w: %w", errInsertSession, err)
+	}
+	id = session.Id(n)
+	for _, d := range s.Actors {
+		if err := addSessionActor(tx, d.Name, id); err != nil {
+			return 0, err
+		}
+	}
+	for _, c := range s.Chunks {
+		if _, err := tx.Exec(`
+INSERT INTO tx (session, text, t, actor, node) VALUES ($1, $2, $3, $4, $5)
+		`, int(id), c.Text, c.Timestamp,
+			nullString(string(c.Actor)), nullString(c.Node)); err != nil {
+			return 0, fmt.Errorf("%w: %w", errInsertTx, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%w: commit: %w", errImportSession, err)
+	}
+	return id, nil
+}
+
+// insertTheme adds a row per keyword, a theme without any has a single row
+// with an empty category.
+func insertTheme(db execer, d theme.Description) error {
+	type row struct{ category, keyword string }
+	rows := []row{}
+	for _, c := range d.Categories {
+		for _, k := range c.Keywords {
+			rows = append(rows, row{c.Name, string(k)})
+		}
+	}
+	if len(rows) == 0 {
+		rows = append(rows, row{})
+	}
+	for _, r := range rows {
+		if _, err := db.Exec(`
+INSERT INTO themes (name, title, category, keyword) VALUES ($1, $2, $3, $4)
+		`, string(d.Name), nullString(d.Title), r.category, r.keyword); err != nil {
+			return fmt.Errorf("%w: insert theme %q: %w", errImportSession, d.Name, err)
+		}
+	}
+	return nil
+}
@@ -44,6 +44,14 @@ func defaultConfig() Config {
 	}
 }
 
+// OptionPath sets the database file.
+func OptionPath(path string) Option {
+	return func(c Config) Config {
+		c.path = path
+		return c
+	}
+}
+
 type adapter struct {
 	db   *sql.DB
 	conf Config
@@ -132,7 +140,10 @@ ON CONFLICT(actor) DO UPDATE SET islocked = ?
 }
 
 func (a adapter) LockActor(name actor.Name, id session.Id) error {
-	return a.setLock(true, name, id)
+	if err := a.setLock(true, name, id); err != nil {
+		return err
+	}
+	return addSessionActor(a.db, name, id)
 }
 
 func (a adapter) UnlockActor(name actor.Name, id session.Id) error {
@@ -286,10 +297,49 @@ SELECT actor FROM actors_locks WHERE islocked = 1
 
 func (a adapter) SaveTranscriptChunk(chunk transcript.Chunk, id session.Id) error {
 	_, err := a.db.Exec(`
-INSERT INTO tx (session, text, t8601) VALUES(?, ?, ?)
-	`, int(id), chunk.Text, chunk.Timestamp.Format(iso8601))
+INSERT INTO tx (session, text, t8601, actor, node) VALUES(?, ?, ?, ?, ?)
+	`, int(id), chunk.Text, chunk.Timestamp.Format(iso8601),
+		nullString(string(chunk.Actor)), nullString(chunk.Node))
 	if err != nil {
 		return fmt.Errorf("%w: %w", errInsertTx, err)
 	}
 	return nil
 }
+
+func (a adapter) Transcript(id session.Id) ([]transcript.Chunk, error) {
+	rows, err := a.db.Query(`
+SELECT text, t8601, actor, node FROM tx WHERE session = ? ORDER BY t8601, id
+	`, int(id))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errSelectTx, err)
+	}
+	defer rows.Close()
+	chunks := []transcript.Chunk{}
+	for rows.Next() {
+		var row struct {
+			text, t8601 string
+			actor, node sql.NullString
+		}
+		if err := rows.Scan(&row.text, &row.t8601, &row.actor, &row.node); err != nil {
+			return nil, fmt.Errorf("%w: %w: %w", errSelectTx, errScan, err)
+		}
+		t, err := time.ParseInLocation(iso8601, row.t8601, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%w: parse %q: %w", errSelectTx, row.t8601, err)
+		}
+		chunks = append(chunks, transcript.Chunk{
+			Text:      row.text,
+			Timestamp: t,
+			Actor:     actor.Name(row.actor.String),
+			Node:      row.node.String,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errSelectTx, err)
+	}
+	return chunks, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,57 @@
+package sqlite
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/malikbenkirane/groq-whisper/host/internal/repo"
+	"github.com/malikbenkirane/groq-whisper/host/internal/repo/repotest"
+)
+
+func TestTheatre(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repo.Theatre {
+		r, err := New(OptionPath(filepath.Join(t.TempDir(), "state.db")))
+		if err != nil {
+			t.Fatalf("new: %s", err)
+		}
+		db := r.(adapter).db
+		t.Cleanup(func() { _ = db.Close() })
+
+		migrations, err := filepath.Glob("../../../../migrations/*.sql")
+		if err != nil {
+			t.Fatalf("glob migrations: %s", err)
+		}
+		sort.Strings(migrations)
+		for _, m := range migrations {
+			stmts, err := os.ReadFile(m)
+			if err != nil {
+				t.Fatalf("read %q: %s", m, err)
+			}
+			if _, err := db.Exec(string(stmts)); err != nil {
+				t.Fatalf("migrate %q: %s", m, err)
+			}
+		}
+
+		for _, th := range repotest.Themes {
+			for _, c := range th.Categories {
+				for _, k := range c.Keywords {
+					if _, err := db.Exec(`
+INSERT INTO themes (name, title, category, keyword) VALUES (?, ?, ?, ?)
+					`, string(th.Name), th.Title, c.Name, string(k)); err != nil {
+						t.Fatalf("seed themes: %s", err)
+					}
+				}
+			}
+		}
+		for _, a := range repotest.Actors {
+			if _, err := db.Exec(`
+INSERT INTO actors (name, site) VALUES (?, ?)
+			`, string(a.Name), string(a.Site)); err != nil {
+				t.Fatalf("seed actors: %s", err)
+			}
+		}
+		return r
+	})
+}
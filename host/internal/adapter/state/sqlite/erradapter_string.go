@@ -30,12 +30,23 @@ func _() {
 	_ = x[errThemes-19]
 	_ = x[errActors-20]
 	_ = x[errInsertTx-21]
-	_ = x[errUnknown-22]
+	_ = x[errSelectTx-22]
+	_ = x[errSearchQuery-23]
+	_ = x[errSearchScan-24]
+	_ = x[errSearchIter-25]
+	_ = x[errExecBindNode-26]
+	_ = x[errDeleteNodesActors-27]
+	_ = x[errSelectNodesActors-28]
+	_ = x[errQueryRowSession-29]
+	_ = x[errSelectSessionsActors-30]
+	_ = x[errExecSessionsActors-31]
+	_ = x[errImportSession-32]
+	_ = x[errUnknown-33]
 }
 
-const _errAdapter_name = "errZeroerrOpenDBerrSelectThemeserrSelectThemesItererrSelectThemesScanerrSelectActorserrSelectActorsItererrSelectActorsScanerrExecSetLockerrQueryRowActorsLockserrDeleteActorsLockserrReadRowsAffectederrInsertSessionerrUpdateSessionerrQueryRowCurrentSessionerrSelectActorsLockserrQueryRowActorserrScanerrActorsSessionerrThemeserrActorserrInsertTxerrUnknown"
+const _errAdapter_name = "errZeroerrOpenDBerrSelectThemeserrSelectThemesItererrSelectThemesScanerrSelectActorserrSelectActorsItererrSelectActorsScanerrExecSetLockerrQueryRowActorsLockserrDeleteActorsLockserrReadRowsAffectederrInsertSessionerrUpdateSessionerrQueryRowCurrentSessionerrSelectActorsLockserrQueryRowActorserrScanerrActorsSessionerrThemeserrActorserrInsertTxerrSelectTxerrSearchQueryerrSearchScanerrSearchItererrExecBindNodeerrDeleteNodesActorserrSelectNodesActorserrQueryRowSessionerrSelectSessionsActorserrExecSessionsActorserrImportSessionerrUnknown"
 
-var _errAdapter_index = [...]uint16{0, 7, 16, 31, 50, 69, 84, 103, 122, 136, 158, 178, 197, 213, 229, 254, 274, 291, 298, 314, 323, 332, 343, 353}
+var _errAdapter_index = [...]uint16{0, 7, 16, 31, 50, 69, 84, 103, 122, 136, 158, 178, 197, 213, 229, 254, 274, 291, 298, 314, 323, 332, 343, 354, 368, 381, 394, 409, 429, 449, 467, 490, 511, 527, 537}
 
 func (i errAdapter) String() string {
 	idx := int(i) - 0
@@ -30,5 +30,16 @@ const (
 	errThemes
 	errActors
 	errInsertTx
+	errSelectTx
+	errSearchQuery
+	errSearchScan
+	errSearchIter
+	errExecBindNode
+	errDeleteNodesActors
+	errSelectNodesActors
+	errQueryRowSession
+	errSelectSessionsActors
+	errExecSessionsActors
+	errImportSession
 	errUnknown
 )
@@ -0,0 +1,85 @@
+package sqlite
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/search"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/session"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/theme"
+)
+
+// Search queries the tx_fts full-text index, best ranked chunks first.
+func (a adapter) Search(q search.Query) ([]search.Hit, error) {
+	if err := q.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errSearchQuery, err)
+	}
+	var from, to string
+	if !q.From.IsZero() {
+		from = q.From.In(time.Local).Format(iso8601)
+	}
+	if !q.To.IsZero() {
+		to = q.To.In(time.Local).Format(iso8601)
+	}
+	rows, err := a.db.Query(`
+SELECT tx.session, s.theme, tx.t8601, snippet(tx_fts, 0, ?, ?, ?, ?)
+FROM tx_fts
+JOIN tx ON tx.id = tx_fts.rowid
+JOIN sessions s ON s.id = tx.session
+WHERE tx_fts MATCH ?
+AND (? = '' OR s.theme = ?)
+AND (? = '' OR tx.t8601 >= ?)
+AND (? = '' OR tx.t8601 <= ?)
+AND (? = '' OR tx.actor = ? OR (tx.actor IS NULL AND EXISTS (
+	SELECT 1 FROM actors_locks l WHERE l.session = tx.session AND l.actor = ?
+)))
+ORDER BY rank
+LIMIT ?
+	`,
+		search.HighlightStart, search.HighlightEnd, search.Ellipsis, search.SnippetTokens,
+		ftsQuery(q),
+		string(q.Theme), string(q.Theme),
+		from, from,
+		to, to,
+		string(q.Actor), string(q.Actor), string(q.Actor),
+		q.Max())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errSearchQuery, err)
+	}
+	defer rows.Close()
+	hits := []search.Hit{}
+	for rows.Next() {
+		var row struct {
+			session      int
+			theme, t8601 string
+			snippet      string
+		}
+		if err := rows.Scan(&row.session, &row.theme, &row.t8601, &row.snippet); err != nil {
+			return nil, fmt.Errorf("%w: %w", errSearchScan, err)
+		}
+		t, err := time.ParseInLocation(iso8601, row.t8601, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%w: parse %q: %w", errSearchScan, row.t8601, err)
+		}
+		hits = append(hits, search.Hit{
+			Session:   session.Id(row.session),
+			Theme:     theme.Name(row.theme),
+			Timestamp: t,
+			Snippet:   row.snippet,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errSearchIter, err)
+	}
+	return hits, nil
+}
+
+// ftsQuery quotes every term so user input is never parsed as fts5 syntax.
+func ftsQuery(q search.Query) string {
+	terms := q.Terms()
+	for i, t := range terms {
+		terms[i] = `"` + t + `"`
+	}
+	return strings.Join(terms, " ")
+}
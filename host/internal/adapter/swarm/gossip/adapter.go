@@ -0,0 +1,303 @@
+package gossip
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/serf/serf"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/actor"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/node"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/theme"
+	"github.com/malikbenkirane/groq-whisper/host/internal/repo"
+)
+
+// New joins the host to the recorders serf swarm. Bindings kept in r are
+// pushed to nodes when they join and every reconcile period.
+func New(r repo.Theatre, opts ...Option) (Adapter, error) {
+	conf := DefaultConfig()
+	for _, opt := range opts {
+		conf = opt(conf)
+	}
+	events := make(chan serf.Event, 64)
+	sc := serf.DefaultConfig()
+	sc.Init()
+	sc.NodeName = conf.name
+	sc.MemberlistConfig.BindAddr = "0.0.0.0"
+	sc.MemberlistConfig.BindPort = conf.port
+	sc.EventCh = events
+	if err := conf.keyring(sc); err != nil {
+		return nil, err
+	}
+	instance, err := serf.Create(sc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errSerfCreate, err)
+	}
+	slog.Info("serf host ready", "node_name", conf.name, "bind_port", conf.port)
+	return &adapter{
+		config: conf,
+		repo:   r,
+		serf:   instance,
+		events: events,
+		acks:   &acks{byTheme: make(map[theme.Name][]node.Ack)},
+		epoch:  time.Now().UnixNano(),
+	}, nil
+}
+
+type Adapter interface {
+	repo.Swarm
+	Serve(ctx context.Context) error
+}
+
+type Config struct {
+	name      string
+	port      int
+	join      []string
+	reconcile time.Duration
+
+	key            []byte
+	keyringFile    string
+	allowPlaintext bool
+}
+
+type Option func(Config) Config
+
+func DefaultConfig() Config {
+	return Config{
+		name:      "groq-host",
+		port:      7496,
+		reconcile: 30 * time.Second,
+	}
+}
+
+func OptionName(name string) Option {
+	return func(c Config) Config {
+		c.name = name
+		return c
+	}
+}
+
+func OptionPort(port int) Option {
+	return func(c Config) Config {
+		c.port = port
+		return c
+	}
+}
+
+// OptionJoin lists serf addresses to join, recorders may as well join the
+// host themselves.
+func OptionJoin(addrs ...string) Option {
+	return func(c Config) Config {
+		c.join = addrs
+		return c
+	}
+}
+
+// OptionKey encrypts the gossip with the base64 key shared by the recorders.
+func OptionKey(key []byte) Option {
+	return func(c Config) Config {
+		c.key = key
+		return c
+	}
+}
+
+// OptionKeyringFile persists the gossip keys rotated by groq swarm, its keys
+// win over OptionKey.
+func OptionKeyringFile(p string) Option {
+	return func(c Config) Config {
+		c.keyringFile = p
+		return c
+	}
+}
+
+func OptionAllowPlaintext() Option {
+	return func(c Config) Config {
+		c.allowPlaintext = true
+		return c
+	}
+}
+
+type adapter struct {
+	config Config
+	repo   repo.Theatre
+	serf   *serf.Serf
+	events chan serf.Event
+	acks   *acks
+	// epoch tells the nodes apart the sessions of this run from the ones
+	// of a previous run, whose ids may be higher.
+	epoch int64
+}
+
+// Serve runs the swarm until ctx is done, a failed join is retried on the
+// reconcile ticks so that the host starts before the recorders.
+func (a adapter) Serve(ctx context.Context) error {
+	joined := a.join()
+	t := time.NewTicker(a.config.reconcile)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := a.serf.Leave(); err != nil {
+				return fmt.Errorf("%w: %w", errSerfLeave, err)
+			}
+			return a.serf.Shutdown()
+		case <-t.C:
+			if !joined {
+				joined = a.join()
+			}
+			a.reconcile(a.serf.Members()...)
+		case e := <-a.events:
+			if ev, ok := e.(serf.UserEvent); ok && ev.Name == eventAlarm {
+				alarm(ev.Payload)
+				continue
+			}
+			ev, ok := e.(serf.MemberEvent)
+			if !ok {
+				continue
+			}
+			for _, m := range ev.Members {
+				slog.Info("serf: member event", "type", ev.EventType(), "name", m.Name, "addr", m.Addr)
+			}
+			switch ev.EventType() {
+			case serf.EventMemberJoin, serf.EventMemberUpdate:
+				a.reconcile(ev.Members...)
+			}
+		}
+	}
+}
+
+// join joins the configured addresses, it is true once one of them is
+// joined or when there are none.
+func (a adapter) join() bool {
+	if len(a.config.join) == 0 {
+		return true
+	}
+	if _, err := a.serf.Join(a.config.join, false); err != nil {
+		slog.Warn("serf: join", "addrs", a.config.join, "err", fmt.Errorf("%w: %w", errSerfJoin, err))
+		return false
+	}
+	return true
+}
+
+// alarm warns about a recorder input alarm so that a facilitator notices a
+// muted or saturated microphone.
+func alarm(payload []byte) {
+	var p alarmPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		slog.Warn("serf: bad alarm payload", "err", err)
+		return
+	}
+	attrs := []any{
+		"node", p.Node, "theme", p.Theme, "actor", p.Actor,
+		"alarm", p.Alarm, "channel", p.Channel, "dbfs", p.DBFS,
+	}
+	if p.Raised {
+		slog.Warn("serf: input alarm raised", attrs...)
+		return
+	}
+	slog.Info("serf: input alarm cleared", attrs...)
+}
+
+// reconcile pushes bindings to the alive members advertising another actor.
+func (a adapter) reconcile(members ...serf.Member) {
+	bindings, err := a.repo.NodeBindings()
+	if err != nil {
+		slog.Error("serf: reconcile", "err", err)
+		return
+	}
+	for _, m := range members {
+		bound, ok := bindings[node.Name(m.Name)]
+		if !ok || m.Status != serf.StatusAlive || m.Tags[tagActor] == string(bound) {
+			continue
+		}
+		slog.Info("serf: pushing binding", "node", m.Name, "actor", bound, "advertised", m.Tags[tagActor])
+		if err := a.push(node.Name(m.Name), bound); err != nil {
+			slog.Error("serf: reconcile", "node", m.Name, "err", err)
+		}
+	}
+}
+
+func (a adapter) push(name node.Name, bound actor.Name) error {
+	payload, err := json.Marshal(bindPayload{Node: string(name), Actor: string(bound)})
+	if err != nil {
+		return fmt.Errorf("%w: %w", errJsonEncode, err)
+	}
+	if err := a.serf.UserEvent(eventBind, payload, false); err != nil {
+		return fmt.Errorf("%w: %w", errSerfUserEvent, err)
+	}
+	return nil
+}
+
+func (a adapter) Nodes() ([]node.Node, error) {
+	actors, err := a.repo.Actors()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errRepoActors, err)
+	}
+	bindings, err := a.repo.NodeBindings()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errRepoNodeBindings, err)
+	}
+	nodes := []node.Node{}
+	for _, m := range a.serf.Members() {
+		if m.Name == a.config.name {
+			continue
+		}
+		n := node.Node{
+			Name:    node.Name(m.Name),
+			Addr:    fmt.Sprintf("%s:%d", m.Addr, m.Port),
+			Status:  m.Status.String(),
+			Theme:   theme.Name(m.Tags[tagTheme]),
+			Version: m.Tags[tagVersion],
+			Binding: bindings[node.Name(m.Name)],
+		}
+		if caps := m.Tags[tagCaps]; caps != "" {
+			n.Capabilities = strings.Split(caps, ",")
+		}
+		if alarms := m.Tags[tagAlarms]; alarms != "" {
+			n.Alarms = strings.Split(alarms, ",")
+		}
+		if levels := m.Tags[tagLevels]; levels != "" {
+			for _, l := range strings.Split(levels, ",") {
+				dbfs, err := strconv.Atoi(l)
+				if err != nil {
+					n.Levels = nil
+					break
+				}
+				n.Levels = append(n.Levels, dbfs)
+			}
+		}
+		if name := m.Tags[tagActor]; name != "" {
+			n.Actor = actor.Description{
+				Name: actor.Name(name),
+				Site: actors[name],
+			}
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+func (a adapter) Bind(name node.Name, bound actor.Name) error {
+	actors, err := a.repo.Actors()
+	if err != nil {
+		return fmt.Errorf("%w: %w", errRepoActors, err)
+	}
+	if _, ok := actors[string(bound)]; !ok {
+		return fmt.Errorf("%w: %q", errUnknownActor, bound)
+	}
+	if err := a.repo.BindNode(name, bound); err != nil {
+		return fmt.Errorf("%w: %w", errRepoBindNode, err)
+	}
+	return a.push(name, bound)
+}
+
+func (a adapter) Unbind(name node.Name) error {
+	if err := a.repo.UnbindNode(name); err != nil {
+		return fmt.Errorf("%w: %w", errRepoUnbindNode, err)
+	}
+	return a.push(name, "")
+}
@@ -0,0 +1,29 @@
+package gossip
+
+//go:generate stringer -type=errGossip
+type errGossip int
+
+func (err errGossip) Error() string {
+	return err.String()
+}
+
+const (
+	errZero errGossip = iota
+	errSerfCreate
+	errSerfJoin
+	errSerfLeave
+	errSerfUserEvent
+	errUnknownActor
+	errJsonEncode
+	errRepoActors
+	errRepoNodeBindings
+	errRepoBindNode
+	errRepoUnbindNode
+	errSerfQuery
+	errJsonDecode
+	errDecodeKey
+	errReadKeyring
+	errNewKeyring
+	errPlaintext
+	errUnknown
+)
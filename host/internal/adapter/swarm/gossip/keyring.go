@@ -0,0 +1,68 @@
+package gossip
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/hashicorp/serf/serf"
+)
+
+// DecodeKey decodes and validates a base64 gossip key.
+func DecodeKey(key string) ([]byte, error) {
+	b, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errDecodeKey, err)
+	}
+	if err := memberlist.ValidateKey(b); err != nil {
+		return nil, fmt.Errorf("%w: %w", errDecodeKey, err)
+	}
+	return b, nil
+}
+
+// keyring mirrors the recorders: keys of the keyring file first, then the
+// key, and plaintext only when allowed.
+func (c Config) keyring(sc *serf.Config) error {
+	var keys [][]byte
+	if c.keyringFile != "" {
+		b, err := os.ReadFile(c.keyringFile)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%w: %w", errReadKeyring, err)
+		}
+		if err == nil {
+			var encoded []string
+			if err := json.Unmarshal(b, &encoded); err != nil {
+				return fmt.Errorf("%w: %w", errReadKeyring, err)
+			}
+			for _, e := range encoded {
+				key, err := DecodeKey(e)
+				if err != nil {
+					return fmt.Errorf("%w: %w", errReadKeyring, err)
+				}
+				keys = append(keys, key)
+			}
+		}
+	}
+	if len(keys) == 0 && c.key != nil {
+		keys = [][]byte{c.key}
+	}
+	if len(keys) == 0 {
+		if !c.allowPlaintext {
+			return errPlaintext
+		}
+		slog.Warn("serf gossip is not encrypted")
+		return nil
+	}
+	kr, err := memberlist.NewKeyring(keys, keys[0])
+	if err != nil {
+		return fmt.Errorf("%w: %w", errNewKeyring, err)
+	}
+	sc.MemberlistConfig.Keyring = kr
+	sc.KeyringFile = c.keyringFile
+	return nil
+}
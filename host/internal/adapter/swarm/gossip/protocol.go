@@ -0,0 +1,55 @@
+package gossip
+
+import "time"
+
+// Serf tags and events shared with the recorder nodes, they mirror
+// internal/server of the groq module.
+const (
+	tagActor   = "actor"
+	tagTheme   = "theme"
+	tagVersion = "version"
+	tagCaps    = "caps"
+	tagLevels  = "levels"
+	tagAlarms  = "alarms"
+
+	eventBind   = "groq-bind"
+	eventAlarm  = "groq-alarm"
+	eventRecord = "groq-record"
+	queryRecord = "groq-record-ack"
+
+	actionStart = "start"
+	actionStop  = "stop"
+)
+
+type bindPayload struct {
+	Node  string `json:"node"`
+	Actor string `json:"actor"`
+}
+
+type alarmPayload struct {
+	Node    string  `json:"node"`
+	Theme   string  `json:"theme"`
+	Actor   string  `json:"actor"`
+	Alarm   string  `json:"alarm"`
+	Raised  bool    `json:"raised"`
+	Channel int     `json:"channel,omitempty"`
+	DBFS    float64 `json:"dbfs"`
+}
+
+type recordPayload struct {
+	Theme   string `json:"theme"`
+	Session int    `json:"session"`
+	Action  string `json:"action"`
+	// Epoch is when the host run started in unix nanoseconds, the nodes
+	// order the sessions of a run by id.
+	Epoch int64 `json:"epoch"`
+}
+
+type recordAck struct {
+	Node      string    `json:"node"`
+	Theme     string    `json:"theme"`
+	Session   int       `json:"session"`
+	Recording bool      `json:"recording"`
+	Since     time.Time `json:"since"`
+	Err       string    `json:"err,omitempty"`
+}
@@ -0,0 +1,73 @@
+package gossip
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/node"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/session"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/theme"
+)
+
+// acks keeps the acknowledgements of the last Record of each theme.
+type acks struct {
+	mu      sync.Mutex
+	byTheme map[theme.Name][]node.Ack
+}
+
+// Record fires the record event to the nodes advertising theme t, then
+// queries them for acknowledgements until the query times out.
+func (a adapter) Record(t theme.Name, id session.Id, start bool) ([]node.Ack, error) {
+	p := recordPayload{Theme: string(t), Session: int(id), Action: actionStop, Epoch: a.epoch}
+	if start {
+		p.Action = actionStart
+	}
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errJsonEncode, err)
+	}
+	if err := a.serf.UserEvent(eventRecord, payload, false); err != nil {
+		return nil, fmt.Errorf("%w: %w", errSerfUserEvent, err)
+	}
+	params := a.serf.DefaultQueryParams()
+	params.FilterTags = map[string]string{
+		tagTheme: "^" + regexp.QuoteMeta(string(t)) + "$",
+	}
+	res, err := a.serf.Query(queryRecord, payload, params)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errSerfQuery, err)
+	}
+	collected := []node.Ack{}
+	for r := range res.ResponseCh() {
+		var ack recordAck
+		if err := json.Unmarshal(r.Payload, &ack); err != nil {
+			slog.Warn("serf: bad record ack", "from", r.From, "err", err)
+			continue
+		}
+		collected = append(collected, node.Ack{
+			Node:      node.Name(r.From),
+			Session:   session.Id(ack.Session),
+			Recording: ack.Recording,
+			Since:     ack.Since,
+			Err:       ack.Err,
+		})
+	}
+	sort.Slice(collected, func(i, j int) bool {
+		return collected[i].Node < collected[j].Node
+	})
+	slog.Info("serf: record", "theme", t, "session", id, "action", p.Action, "acks", len(collected))
+	a.acks.mu.Lock()
+	a.acks.byTheme[t] = collected
+	a.acks.mu.Unlock()
+	return collected, nil
+}
+
+func (a adapter) Acks(t theme.Name) []node.Ack {
+	a.acks.mu.Lock()
+	defer a.acks.mu.Unlock()
+	return a.acks.byTheme[t]
+}
@@ -0,0 +1,482 @@
+// Package archive bundles a session of a theatre into a single tar.gz file
+// any theatre can import. The bundle holds the session metadata, its theme,
+// actors and transcript, optionally the audio chunks of the recorders, and
+// a SHA256SUMS file checked on import.
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/actor"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/session"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/theme"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/transcript"
+	"github.com/malikbenkirane/groq-whisper/host/internal/repo"
+)
+
+// Version is the version of the bundle layout.
+const Version = 1
+
+const (
+	fileSession    = "session.json"
+	fileTheme      = "theme.json"
+	fileActors     = "actors.json"
+	fileTranscript = "transcript.json"
+	fileSums       = "SHA256SUMS"
+	dirAudio       = "audio/"
+)
+
+// maxJson bounds the json files read on import.
+const maxJson = 256 << 20
+
+// audioSlack is how long after the session end a chunk may be cut, the
+// recorders flush their partial chunk once stopped.
+const audioSlack = time.Minute
+
+type sessionJson struct {
+	Version    int       `json:"version"`
+	ID         int       `json:"id"`
+	Theme      string    `json:"theme"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+type themeJson struct {
+	Name       string         `json:"name"`
+	Title      string         `json:"title,omitempty"`
+	Categories []categoryJson `json:"categories"`
+}
+
+type categoryJson struct {
+	Name     string   `json:"name"`
+	Keywords []string `json:"keywords"`
+}
+
+type actorJson struct {
+	Name string `json:"name"`
+	Site string `json:"site"`
+}
+
+type chunkJson struct {
+	Text  string    `json:"text"`
+	Ts    time.Time `json:"ts"`
+	Actor string    `json:"actor,omitempty"`
+	Node  string    `json:"node,omitempty"`
+}
+
+// Export writes the session id of r to w with the audio chunks of audioDirs
+// cut during the session, they are bundled under audio/ and the base name
+// of their directory. A running session is archived as ended at now.
+func Export(w io.Writer, r repo.Theatre, id session.Id, audioDirs []string, now time.Time) error {
+	s, err := r.Session(id)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errRepoSession, err)
+	}
+	if s == nil {
+		return fmt.Errorf("%w: %d", errUnknownSession, id)
+	}
+	chunks, err := r.Transcript(id)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errRepoTranscript, err)
+	}
+	actors, err := actorsOf(r, s.Actors, chunks)
+	if err != nil {
+		return err
+	}
+	meta := sessionJson{
+		Version:    Version,
+		ID:         int(id),
+		Theme:      string(s.Theme.Name),
+		Start:      *s.StartAt(),
+		End:        now,
+		ArchivedAt: now,
+	}
+	if end := s.EndAt(); end != nil {
+		meta.End = *end
+	}
+
+	gz := gzip.NewWriter(w)
+	b := &bundle{tw: tar.NewWriter(gz), sums: []string{}, mtime: now}
+	for _, f := range []struct {
+		name string
+		v    any
+	}{
+		{fileSession, meta},
+		{fileTheme, themeOf(s.Theme)},
+		{fileActors, actors},
+		{fileTranscript, chunksOf(chunks)},
+	} {
+		if err := b.addJson(f.name, f.v); err != nil {
+			return err
+		}
+	}
+	bases := make(map[string]string)
+	for _, dir := range audioDirs {
+		base := filepath.Base(filepath.Clean(dir))
+		if other, ok := bases[base]; ok {
+			return fmt.Errorf("%w: %q and %q have the same name", errAudio, other, dir)
+		}
+		bases[base] = dir
+		if err := b.addAudio(dir, dirAudio+base+"/", meta.Start, meta.End.Add(audioSlack)); err != nil {
+			return err
+		}
+	}
+	sums := []byte(strings.Join(b.sums, ""))
+	if err := b.add(fileSums, int64(len(sums)), bytes.NewReader(sums)); err != nil {
+		return err
+	}
+	if err := b.tw.Close(); err != nil {
+		return fmt.Errorf("%w: %w", errWrite, err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("%w: %w", errWrite, err)
+	}
+	return nil
+}
+
+// bundle writes the tar entries and their sha256sum lines.
+type bundle struct {
+	tw    *tar.Writer
+	sums  []string
+	mtime time.Time
+}
+
+func (b *bundle) add(name string, size int64, r io.Reader) error {
+	if err := b.tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    size,
+		ModTime: b.mtime,
+	}); err != nil {
+		return fmt.Errorf("%w: %q: %w", errWrite, name, err)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(b.tw, h), r); err != nil {
+		return fmt.Errorf("%w: %q: %w", errWrite, name, err)
+	}
+	if name != fileSums {
+		b.sums = append(b.sums, fmt.Sprintf("%s  %s\n", hex.EncodeToString(h.Sum(nil)), name))
+	}
+	return nil
+}
+
+func (b *bundle) addJson(name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("%w: %q: %w", errJsonEncode, name, err)
+	}
+	return b.add(name, int64(len(data)), bytes.NewReader(data))
+}
+
+// addAudio adds the encoded chunks of dir, sealed or not, cut from start
+// to end.
+func (b *bundle) addAudio(dir, prefix string, start, end time.Time) error {
+	dirents, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errAudio, err)
+	}
+	for _, d := range dirents {
+		name := d.Name()
+		if !d.Type().IsRegular() ||
+			!strings.HasSuffix(name, ".flac") && !strings.HasSuffix(name, ".flac.age") {
+			continue
+		}
+		// the sampler names the chunks after their cut, the layout parses
+		// the milliseconds after the comma
+		ts, _, _ := strings.Cut(name, ".")
+		t, err := time.ParseInLocation("20060102150405", ts, time.Local)
+		if err != nil || t.Before(start.Truncate(time.Second)) || t.After(end) {
+			continue
+		}
+		if err := b.addFile(filepath.Join(dir, name), prefix+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *bundle) addFile(p, name string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errAudio, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("%w: %w", errAudio, err)
+	}
+	return b.add(name, info.Size(), f)
+}
+
+func themeOf(d theme.Description) themeJson {
+	t := themeJson{Name: string(d.Name), Title: d.Title, Categories: []categoryJson{}}
+	for _, c := range d.Categories {
+		keywords := make([]string, len(c.Keywords))
+		for i, k := range c.Keywords {
+			keywords[i] = string(k)
+		}
+		t.Categories = append(t.Categories, categoryJson{Name: c.Name, Keywords: keywords})
+	}
+	return t
+}
+
+// actorsOf are the actors of the session and the ones the chunks are
+// attributed to, which may have been unlocked since.
+func actorsOf(r repo.Theatre, actors []actor.Description, chunks []transcript.Chunk) ([]actorJson, error) {
+	a := make([]actorJson, len(actors))
+	seen := make(map[actor.Name]bool)
+	for i, d := range actors {
+		a[i] = actorJson{Name: string(d.Name), Site: string(d.Site)}
+		seen[d.Name] = true
+	}
+	var sites map[string]actor.Call
+	for _, c := range chunks {
+		if c.Actor == "" || seen[c.Actor] {
+			continue
+		}
+		if sites == nil {
+			var err error
+			if sites, err = r.Actors(); err != nil {
+				return nil, fmt.Errorf("%w: %w", errRepoActors, err)
+			}
+		}
+		seen[c.Actor] = true
+		a = append(a, actorJson{Name: string(c.Actor), Site: string(sites[string(c.Actor)])})
+	}
+	return a, nil
+}
+
+func chunksOf(chunks []transcript.Chunk) []chunkJson {
+	c := make([]chunkJson, len(chunks))
+	for i, chunk := range chunks {
+		c[i] = chunkJson{
+			Text:  chunk.Text,
+			Ts:    chunk.Timestamp,
+			Actor: string(chunk.Actor),
+			Node:  chunk.Node,
+		}
+	}
+	return c
+}
+
+// Imported is what Import restored.
+type Imported struct {
+	// ID is the new id of the session, From its id in the archive.
+	ID, From session.Id
+	Theme    theme.Name
+	Chunks   int
+	// Audio are the paths of the audio chunks extracted.
+	Audio []string
+}
+
+// Import verifies the archive of rd and imports its session into r under a
+// new id. The audio chunks are extracted under audioDir, keeping their path
+// below audio/, or skipped when audioDir is empty. Nothing is imported when
+// a checksum does not match.
+func Import(rd io.Reader, r repo.Theatre, audioDir string) (imported Imported, err error) {
+	gz, err := gzip.NewReader(rd)
+	if err != nil {
+		return imported, fmt.Errorf("%w: %w", errRead, err)
+	}
+	var tmp string
+	if audioDir != "" {
+		if err := os.MkdirAll(audioDir, 0700); err != nil {
+			return imported, fmt.Errorf("%w: %w", errAudio, err)
+		}
+		// the audio is moved in place once the session is imported
+		if tmp, err = os.MkdirTemp(audioDir, ".import-"); err != nil {
+			return imported, fmt.Errorf("%w: %w", errAudio, err)
+		}
+		defer os.RemoveAll(tmp)
+	}
+
+	files := make(map[string][]byte)
+	computed := make(map[string]string)
+	var audio []string
+	var sums []byte
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return imported, fmt.Errorf("%w: %w", errRead, err)
+		}
+		name := hdr.Name
+		if hdr.Typeflag != tar.TypeReg || path.IsAbs(name) || path.Clean(name) != name ||
+			name == ".." || strings.HasPrefix(name, "../") {
+			return imported, fmt.Errorf("%w: %q", errEntry, name)
+		}
+		if _, ok := computed[name]; ok || name == fileSums && sums != nil {
+			return imported, fmt.Errorf("%w: %q twice", errEntry, name)
+		}
+		h := sha256.New()
+		switch {
+		case name == fileSums:
+			if sums, err = io.ReadAll(io.LimitReader(tr, maxJson)); err != nil {
+				return imported, fmt.Errorf("%w: %q: %w", errRead, name, err)
+			}
+			continue
+		case strings.HasPrefix(name, dirAudio):
+			if tmp == "" {
+				if _, err := io.Copy(h, tr); err != nil {
+					return imported, fmt.Errorf("%w: %q: %w", errRead, name, err)
+				}
+				break
+			}
+			if err := extract(filepath.Join(tmp, filepath.FromSlash(strings.TrimPrefix(name, dirAudio))), io.TeeReader(tr, h)); err != nil {
+				return imported, fmt.Errorf("%w: %q: %w", errAudio, name, err)
+			}
+			audio = append(audio, name)
+		default:
+			b, err := io.ReadAll(io.LimitReader(io.TeeReader(tr, h), maxJson))
+			if err != nil {
+				return imported, fmt.Errorf("%w: %q: %w", errRead, name, err)
+			}
+			files[name] = b
+		}
+		computed[name] = hex.EncodeToString(h.Sum(nil))
+	}
+	if err := verify(sums, computed); err != nil {
+		return imported, err
+	}
+
+	s, meta, err := decode(files)
+	if err != nil {
+		return imported, err
+	}
+	id, err := r.ImportSession(s)
+	if err != nil {
+		return imported, fmt.Errorf("%w: %w", errRepoImportSession, err)
+	}
+	imported = Imported{ID: id, From: session.Id(meta.ID), Theme: s.Theme.Name, Chunks: len(s.Chunks)}
+	for _, name := range audio {
+		rel := filepath.FromSlash(strings.TrimPrefix(name, dirAudio))
+		p := filepath.Join(audioDir, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			return imported, fmt.Errorf("%w: %w", errAudio, err)
+		}
+		if err := os.Rename(filepath.Join(tmp, rel), p); err != nil {
+			return imported, fmt.Errorf("%w: %w", errAudio, err)
+		}
+		imported.Audio = append(imported.Audio, p)
+	}
+	return imported, nil
+}
+
+func extract(p string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// verify checks that the files of the archive are the ones of sums, a
+// sha256sum output.
+func verify(sums []byte, computed map[string]string) error {
+	if sums == nil {
+		return fmt.Errorf("%w: no %s", errChecksum, fileSums)
+	}
+	listed := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		sum, name, ok := strings.Cut(scanner.Text(), "  ")
+		if !ok {
+			return fmt.Errorf("%w: malformed line %q", errChecksum, scanner.Text())
+		}
+		listed[name] = sum
+	}
+	for name, sum := range listed {
+		got, ok := computed[name]
+		if !ok {
+			return fmt.Errorf("%w: %q is missing", errChecksum, name)
+		}
+		if got != sum {
+			return fmt.Errorf("%w: %q does not match", errChecksum, name)
+		}
+	}
+	for name := range computed {
+		if _, ok := listed[name]; !ok {
+			return fmt.Errorf("%w: %q is not listed", errChecksum, name)
+		}
+	}
+	return nil
+}
+
+func decode(files map[string][]byte) (s session.Session, meta sessionJson, err error) {
+	var (
+		th     themeJson
+		actors []actorJson
+		chunks []chunkJson
+	)
+	for _, f := range []struct {
+		name string
+		v    any
+	}{
+		{fileSession, &meta},
+		{fileTheme, &th},
+		{fileActors, &actors},
+		{fileTranscript, &chunks},
+	} {
+		b, ok := files[f.name]
+		if !ok {
+			return s, meta, fmt.Errorf("%w: no %s", errEntry, f.name)
+		}
+		if err := json.Unmarshal(b, f.v); err != nil {
+			return s, meta, fmt.Errorf("%w: %s: %w", errJsonDecode, f.name, err)
+		}
+	}
+	if meta.Version != Version {
+		return s, meta, fmt.Errorf("%w: %d, expected %d", errVersion, meta.Version, Version)
+	}
+	s.ID = session.Id(meta.ID)
+	s.Start(meta.Start)
+	s.End(meta.End)
+	s.Theme = theme.Description{Name: theme.Name(meta.Theme), Title: th.Title}
+	for _, c := range th.Categories {
+		keywords := make([]theme.Keyword, len(c.Keywords))
+		for i, k := range c.Keywords {
+			keywords[i] = theme.Keyword(k)
+		}
+		s.Theme.Categories = append(s.Theme.Categories, theme.Category{Name: c.Name, Keywords: keywords})
+	}
+	for _, a := range actors {
+		s.Actors = append(s.Actors, actor.Description{Name: actor.Name(a.Name), Site: actor.Call(a.Site)})
+	}
+	for _, c := range chunks {
+		s.Chunks = append(s.Chunks, transcript.Chunk{
+			Text:      c.Text,
+			Timestamp: c.Ts,
+			Actor:     actor.Name(c.Actor),
+			Node:      c.Node,
+		})
+	}
+	slices.SortStableFunc(s.Chunks, func(a, b transcript.Chunk) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	return s, meta, nil
+}
@@ -0,0 +1,175 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/actor"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/session"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/theme"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/transcript"
+	"github.com/malikbenkirane/groq-whisper/host/internal/repo"
+)
+
+// theatre holds a single session, the methods the archive does not use
+// panic.
+type theatre struct {
+	repo.Theatre
+	session  *session.Session
+	chunks   []transcript.Chunk
+	imported []session.Session
+}
+
+func (t *theatre) Session(id session.Id) (*session.Session, error) {
+	if t.session == nil || t.session.ID != id {
+		return nil, nil
+	}
+	return t.session, nil
+}
+
+func (t *theatre) Transcript(session.Id) ([]transcript.Chunk, error) {
+	return t.chunks, nil
+}
+
+func (t *theatre) Actors() (map[string]actor.Call, error) {
+	return map[string]actor.Call{"carol": "lyon"}, nil
+}
+
+func (t *theatre) ImportSession(s session.Session) (session.Id, error) {
+	t.imported = append(t.imported, s)
+	return session.Id(100 + len(t.imported)), nil
+}
+
+func TestArchive(t *testing.T) {
+	start := time.Date(2026, 10, 19, 14, 0, 0, 0, time.Local)
+	s := &session.Session{
+		ID: 7,
+		Theme: theme.Description{
+			Name:       "ops",
+			Title:      "Operations",
+			Categories: []theme.Category{{Name: "infra", Keywords: []theme.Keyword{"dns"}}},
+		},
+		Actors: []actor.Description{{Name: "alice", Site: "paris"}},
+	}
+	s.Start(start)
+	s.End(start.Add(time.Hour))
+	src := &theatre{session: s, chunks: []transcript.Chunk{
+		{Text: "hello", Timestamp: start.Add(time.Minute), Actor: "alice"},
+		{Text: "dns is down", Timestamp: start.Add(2 * time.Minute), Actor: "carol", Node: "n1"},
+	}}
+
+	audio := filepath.Join(t.TempDir(), "rec")
+	if err := os.Mkdir(audio, 0700); err != nil {
+		t.Fatal(err)
+	}
+	// two chunks cut during the session and one before, and a raw chunk
+	for _, c := range []struct {
+		cut time.Time
+		ext string
+	}{
+		{start.Add(30 * time.Second), ".flac"},
+		{start.Add(time.Hour + 30*time.Second), ".flac.age"},
+		{start.Add(-time.Minute), ".flac"},
+		{start.Add(time.Minute), ".raw"},
+	} {
+		p := filepath.Join(audio, c.cut.Format("20060102150405,000")+c.ext)
+		if err := os.WriteFile(p, []byte(c.ext), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var bundle bytes.Buffer
+	if err := Export(&bundle, src, 7, []string{audio}, start.Add(2*time.Hour)); err != nil {
+		t.Fatalf("export: %s", err)
+	}
+	if err := Export(io.Discard, src, 8, nil, start); !errors.Is(err, errUnknownSession) {
+		t.Errorf("export unknown session: got %v, want %v", err, errUnknownSession)
+	}
+
+	dst := &theatre{}
+	out := t.TempDir()
+	imported, err := Import(bytes.NewReader(bundle.Bytes()), dst, out)
+	if err != nil {
+		t.Fatalf("import: %s", err)
+	}
+	if imported.ID != 101 || imported.From != 7 || imported.Chunks != 2 || len(imported.Audio) != 2 {
+		t.Errorf("imported %+v", imported)
+	}
+	got := dst.imported[0]
+	if got.Theme.Name != "ops" || len(got.Theme.Categories) != 1 {
+		t.Errorf("theme %+v", got.Theme)
+	}
+	if !got.StartAt().Equal(start) || !got.EndAt().Equal(start.Add(time.Hour)) {
+		t.Errorf("session from %v to %v", got.StartAt(), got.EndAt())
+	}
+	if len(got.Actors) != 2 || got.Actors[1] != (actor.Description{Name: "carol", Site: "lyon"}) {
+		t.Errorf("actors %+v", got.Actors)
+	}
+	if len(got.Chunks) != 2 || got.Chunks[1].Node != "n1" {
+		t.Errorf("chunks %+v", got.Chunks)
+	}
+	p := filepath.Join(out, "rec", start.Add(30*time.Second).Format("20060102150405,000")+".flac")
+	if b, err := os.ReadFile(p); err != nil || string(b) != ".flac" {
+		t.Errorf("audio %q: %q, %v", p, b, err)
+	}
+
+	tampered := rewrite(t, bundle.Bytes(), func(name string, b []byte) []byte {
+		if name == fileTranscript {
+			return bytes.Replace(b, []byte("hello"), []byte("HELLO"), 1)
+		}
+		return b
+	})
+	if _, err := Import(bytes.NewReader(tampered), dst, t.TempDir()); !errors.Is(err, errChecksum) {
+		t.Errorf("import tampered: got %v, want %v", err, errChecksum)
+	}
+	if len(dst.imported) != 1 {
+		t.Errorf("tampered archive imported")
+	}
+}
+
+// rewrite copies the archive b changing its entries with f.
+func rewrite(t *testing.T, b []byte, f func(name string, b []byte) []byte) []byte {
+	t.Helper()
+	gr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	gw := gzip.NewWriter(&out)
+	tr, tw := tar.NewReader(gr), tar.NewWriter(gw)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = f(hdr.Name, data)
+		hdr.Size = int64(len(data))
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,26 @@
+package archive
+
+//go:generate stringer -type=errArchive
+type errArchive int
+
+func (err errArchive) Error() string {
+	return err.String()
+}
+
+const (
+	errZero errArchive = iota
+	errRepoSession
+	errUnknownSession
+	errRepoTranscript
+	errRepoActors
+	errRepoImportSession
+	errJsonEncode
+	errJsonDecode
+	errWrite
+	errRead
+	errEntry
+	errChecksum
+	errVersion
+	errAudio
+	errUnknown
+)
@@ -0,0 +1,41 @@
+package node
+
+import (
+	"time"
+
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/actor"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/session"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/theme"
+)
+
+// Node is a recorder taking part in the serf swarm.
+type Node struct {
+	Name         Name
+	Addr         string
+	Status       string
+	Theme        theme.Name
+	Version      string
+	Capabilities []string
+
+	// Actor is the actor the node advertises, Binding the one the host
+	// assigned to it. They differ until the node applies the binding.
+	Actor   actor.Description
+	Binding actor.Name
+
+	// Levels are the dBFS of the last chunk of each input track, Alarms
+	// the input alarms raised such as "silence" or "clipping/ch2".
+	Levels []int
+	Alarms []string
+}
+
+type Name string
+
+// Ack is a node acknowledging a session start or stop.
+type Ack struct {
+	Node      Name
+	Session   session.Id
+	Recording bool
+	Since     time.Time
+	// Err is set when the node failed to apply the request.
+	Err string
+}
@@ -0,0 +1,164 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/actor"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/session"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/theme"
+)
+
+// Query selects transcript chunks holding every term of Text. Zero valued
+// filters are ignored.
+type Query struct {
+	Text  string
+	Theme theme.Name
+	Actor actor.Name
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+type Hit struct {
+	Session   session.Id
+	Theme     theme.Name
+	Timestamp time.Time
+	Snippet   string
+}
+
+// HitJson is the wire form of a Hit shared by GET /search and the search
+// command so the two cannot drift apart.
+type HitJson struct {
+	Session   int    `json:"session"`
+	Theme     string `json:"theme"`
+	Timestamp string `json:"timestamp"`
+	Snippet   string `json:"snippet"`
+}
+
+func (h Hit) Json() HitJson {
+	return HitJson{
+		Session:   int(h.Session),
+		Theme:     string(h.Theme),
+		Timestamp: h.Timestamp.Format(TimeLayout),
+		Snippet:   h.Snippet,
+	}
+}
+
+const (
+	HighlightStart = "["
+	HighlightEnd   = "]"
+	Ellipsis       = "…"
+
+	DefaultLimit = 50
+
+	// TimeLayout is the host's iso8601 layout.
+	TimeLayout = "2006-01-02T15:04:05.000"
+
+	// SnippetTokens is the number of words kept around the first match.
+	SnippetTokens = 16
+)
+
+func (q Query) Terms() []string {
+	return tokens(q.Text)
+}
+
+func (q Query) Validate() error {
+	if len(q.Terms()) == 0 {
+		return fmt.Errorf("empty search query %q", q.Text)
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && q.To.Before(q.From) {
+		return fmt.Errorf("search range ends before it starts")
+	}
+	return nil
+}
+
+func (q Query) Max() int {
+	if q.Limit <= 0 {
+		return DefaultLimit
+	}
+	return q.Limit
+}
+
+// Match is the generic matcher used by adapters without a full-text index.
+// It reports whether text holds every term of q, case insensitively, and
+// returns a snippet of text around the first match with every term
+// highlighted.
+func Match(q Query, text string) (snippet string, ok bool) {
+	terms := q.Terms()
+	if len(terms) == 0 {
+		return "", false
+	}
+	words := strings.Fields(text)
+	first := -1
+	found := make(map[string]bool, len(terms))
+	marked := make([]bool, len(words))
+	for i, w := range words {
+		for _, token := range tokens(w) {
+			for _, term := range terms {
+				if token == term {
+					found[term] = true
+					marked[i] = true
+					if first < 0 {
+						first = i
+					}
+				}
+			}
+		}
+	}
+	if len(found) != len(uniq(terms)) {
+		return "", false
+	}
+	start := max(0, first-SnippetTokens/2)
+	end := min(len(words), start+SnippetTokens)
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString(Ellipsis)
+	}
+	for i := start; i < end; i++ {
+		if i > start {
+			b.WriteByte(' ')
+		}
+		if marked[i] {
+			b.WriteString(HighlightStart + words[i] + HighlightEnd)
+			continue
+		}
+		b.WriteString(words[i])
+	}
+	if end < len(words) {
+		b.WriteString(Ellipsis)
+	}
+	return b.String(), true
+}
+
+// ParseTime accepts RFC 3339 timestamps, the host's iso8601 layout or plain
+// dates.
+func ParseTime(s string) (time.Time, error) {
+	for _, layout := range []string{
+		time.RFC3339,
+		TimeLayout,
+		"2006-01-02T15:04:05",
+		time.DateOnly,
+	} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unsupported time %q", s)
+}
+
+func tokens(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func uniq(terms []string) map[string]struct{} {
+	m := make(map[string]struct{}, len(terms))
+	for _, t := range terms {
+		m[t] = struct{}{}
+	}
+	return m
+}
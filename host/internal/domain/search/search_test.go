@@ -0,0 +1,27 @@
+package search
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	for _, tc := range []struct {
+		q, text, snippet string
+		ok               bool
+	}{
+		{"solar", "Solar panels, on every roof.", "[Solar] panels, on every roof.", true},
+		{"wind solar", "wind turbines beat solar farms", "[wind] turbines beat [solar] farms", true},
+		{"wind solar", "wind turbines", "", false},
+		{"sol", "solar", "", false},
+		{"écoles", "des toits d'écoles", "des toits [d'écoles]", true},
+		{
+			"here",
+			"one two three four five six seven eight nine ten here eleven twelve thirteen fourteen fifteen sixteen seventeen",
+			"…three four five six seven eight nine ten [here] eleven twelve thirteen fourteen fifteen sixteen seventeen",
+			true,
+		},
+	} {
+		snippet, ok := Match(Query{Text: tc.q}, tc.text)
+		if ok != tc.ok || snippet != tc.snippet {
+			t.Errorf("match %q in %q: expected %q %v got %q %v", tc.q, tc.text, tc.snippet, tc.ok, snippet, ok)
+		}
+	}
+}
@@ -22,4 +22,8 @@ type Session struct {
 func (s *Session) Start(t time.Time) { s.startAt = &t }
 func (s *Session) End(t time.Time)   { s.endAt = &t }
 
+// StartAt and EndAt are nil until the session started or ended.
+func (s Session) StartAt() *time.Time { return s.startAt }
+func (s Session) EndAt() *time.Time   { return s.endAt }
+
 type Id int
@@ -2,9 +2,64 @@ package transcript
 
 import (
 	"time"
+
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/actor"
 )
 
+// Chunk is a piece of transcript. Actor and Node are empty when the
+// recorder did not tell who spoke or which recorder node heard it.
 type Chunk struct {
 	Text      string
 	Timestamp time.Time
+	Actor     actor.Name
+	Node      string
+}
+
+// Speaker names who said a chunk, falling back to the recorder node.
+func (c Chunk) Speaker() string {
+	if c.Actor != "" {
+		return string(c.Actor)
+	}
+	return c.Node
+}
+
+// Turn is a run of consecutive chunks said by the same speaker.
+type Turn struct {
+	Actor  actor.Name
+	Node   string
+	Start  time.Time
+	End    time.Time
+	Chunks []Chunk
+}
+
+// Turns groups time ordered chunks by speaker.
+func Turns(chunks []Chunk) []Turn {
+	turns := []Turn{}
+	for _, c := range chunks {
+		if n := len(turns); n > 0 && turns[n-1].Chunks[0].Speaker() == c.Speaker() {
+			turns[n-1].End = c.Timestamp
+			turns[n-1].Chunks = append(turns[n-1].Chunks, c)
+			continue
+		}
+		turns = append(turns, Turn{
+			Actor:  c.Actor,
+			Node:   c.Node,
+			Start:  c.Timestamp,
+			End:    c.Timestamp,
+			Chunks: []Chunk{c},
+		})
+	}
+	return turns
+}
+
+// Text joins the chunks of a turn.
+func (t Turn) Text() string {
+	var b []byte
+	for i, c := range t.Chunks {
+		if i > 0 {
+			b = append(b, ' ')
+		}
+		b = append(b, c.Text...)
+	}
+	return string(b)
 }
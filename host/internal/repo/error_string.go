@@ -19,11 +19,20 @@ func _() {
 	_ = x[ErrStopSession-8]
 	_ = x[ErrCurrentSession-9]
 	_ = x[ErrSaveTranscriptChunk-10]
+	_ = x[ErrTranscript-11]
+	_ = x[ErrSearch-12]
+	_ = x[ErrBindNode-13]
+	_ = x[ErrUnbindNode-14]
+	_ = x[ErrNodeBindings-15]
+	_ = x[ErrNodes-16]
+	_ = x[ErrRecord-17]
+	_ = x[ErrSession-18]
+	_ = x[ErrImportSession-19]
 }
 
-const _Error_name = "ErrThemesErrActorsErrLockActorErrUnlockActorErrGetUnlockedActorsErrIsActorLockedErrResetActorLocksErrStartSessionErrStopSessionErrCurrentSessionErrSaveTranscriptChunk"
+const _Error_name = "ErrThemesErrActorsErrLockActorErrUnlockActorErrGetUnlockedActorsErrIsActorLockedErrResetActorLocksErrStartSessionErrStopSessionErrCurrentSessionErrSaveTranscriptChunkErrTranscriptErrSearchErrBindNodeErrUnbindNodeErrNodeBindingsErrNodesErrRecordErrSessionErrImportSession"
 
-var _Error_index = [...]uint8{0, 9, 18, 30, 44, 64, 80, 98, 113, 127, 144, 166}
+var _Error_index = [...]uint16{0, 9, 18, 30, 44, 64, 80, 98, 113, 127, 144, 166, 179, 188, 199, 212, 227, 235, 244, 254, 270}
 
 func (i Error) String() string {
 	idx := int(i) - 0
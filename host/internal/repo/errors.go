@@ -15,6 +15,15 @@ const (
 	ErrStopSession
 	ErrCurrentSession
 	ErrSaveTranscriptChunk
+	ErrTranscript
+	ErrSearch
+	ErrBindNode
+	ErrUnbindNode
+	ErrNodeBindings
+	ErrNodes
+	ErrRecord
+	ErrSession
+	ErrImportSession
 )
 
 func (err Error) Error() string {
@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/malikbenkirane/groq-whisper/host/internal/domain/actor"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/node"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/search"
 	"github.com/malikbenkirane/groq-whisper/host/internal/domain/session"
 	"github.com/malikbenkirane/groq-whisper/host/internal/domain/theme"
 	"github.com/malikbenkirane/groq-whisper/host/internal/domain/transcript"
@@ -22,8 +24,33 @@ type Theatre interface {
 	StartSession(name theme.Name, t time.Time) error
 	StopSession(name theme.Name, t time.Time) error
 	CurrentSession(name theme.Name) (*session.Session, error)
+	// Session is the session id with its start, end, theme and the actors
+	// that were locked in it, nil when unknown.
+	Session(id session.Id) (*session.Session, error)
+	// ImportSession saves an ended session of another theatre with its
+	// chunks under a new id. Its theme and actors are added when unknown.
+	ImportSession(s session.Session) (session.Id, error)
 
 	SaveTranscriptChunk(chunk transcript.Chunk, id session.Id) error
+	Transcript(id session.Id) ([]transcript.Chunk, error)
+	Search(q search.Query) ([]search.Hit, error)
+
+	BindNode(name node.Name, actor actor.Name) error
+	UnbindNode(name node.Name) error
+	NodeBindings() (map[node.Name]actor.Name, error)
+}
+
+// Swarm is the live view of the recorder nodes.
+type Swarm interface {
+	Nodes() ([]node.Node, error)
+	// Bind records the binding and pushes it to the node.
+	Bind(name node.Name, actor actor.Name) error
+	Unbind(name node.Name) error
+	// Record starts or stops the samplers of the nodes recording for
+	// theme t and returns their acknowledgements.
+	Record(t theme.Name, id session.Id, start bool) ([]node.Ack, error)
+	// Acks are the acknowledgements of the last Record of theme t.
+	Acks(t theme.Name) []node.Ack
 }
 
 type Agent interface {
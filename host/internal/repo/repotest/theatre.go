@@ -0,0 +1,492 @@
+// Package repotest holds the conformance tests every repo.Theatre adapter is
+// expected to pass.
+package repotest
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/actor"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/node"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/search"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/session"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/theme"
+	"github.com/malikbenkirane/groq-whisper/host/internal/domain/transcript"
+	"github.com/malikbenkirane/groq-whisper/host/internal/repo"
+)
+
+// Themes and Actors are the fixture an Open function must load into the
+// theatre it returns.
+var (
+	Themes = []theme.Description{
+		{
+			Name:  "climate",
+			Title: "Climate assembly",
+			Categories: []theme.Category{
+				{Name: "energy", Keywords: []theme.Keyword{"solar", "wind"}},
+				{Name: "food", Keywords: []theme.Keyword{"soil"}},
+			},
+		},
+		{
+			Name:  "city",
+			Title: "City council",
+			Categories: []theme.Category{
+				{Name: "transport", Keywords: []theme.Keyword{"bus", "bike"}},
+			},
+		},
+	}
+	Actors = []actor.Description{
+		{Name: "alice", Site: "north"},
+		{Name: "bob", Site: "south"},
+		{Name: "carol", Site: "east"},
+	}
+)
+
+// Open returns a theatre holding the Themes and Actors fixture and nothing
+// else. It is called once per conformance test.
+type Open func(t *testing.T) repo.Theatre
+
+// Run runs the conformance tests against the theatres returned by open.
+func Run(t *testing.T, open Open) {
+	t.Run("Themes", func(t *testing.T) { testThemes(t, open(t)) })
+	t.Run("Actors", func(t *testing.T) { testActors(t, open(t)) })
+	t.Run("Sessions", func(t *testing.T) { testSessions(t, open(t)) })
+	t.Run("Locks", func(t *testing.T) { testLocks(t, open(t)) })
+	t.Run("Transcript", func(t *testing.T) { testTranscript(t, open(t)) })
+	t.Run("Search", func(t *testing.T) { testSearch(t, open(t)) })
+	t.Run("NodeBindings", func(t *testing.T) { testNodeBindings(t, open(t)) })
+	t.Run("Import", func(t *testing.T) { testImport(t, open(t)) })
+}
+
+func testThemes(t *testing.T, r repo.Theatre) {
+	got, err := r.Themes()
+	if err != nil {
+		t.Fatalf("themes: %s", err)
+	}
+	if len(got) != len(Themes) {
+		t.Fatalf("expected %d themes got %d", len(Themes), len(got))
+	}
+	for _, want := range Themes {
+		g, ok := got[string(want.Name)]
+		if !ok {
+			t.Fatalf("missing theme %q", want.Name)
+		}
+		if g.Title != want.Title {
+			t.Errorf("theme %q: expected title %q got %q", want.Name, want.Title, g.Title)
+		}
+		if a, b := categories(g), categories(want); a != b {
+			t.Errorf("theme %q: expected categories %s got %s", want.Name, b, a)
+		}
+	}
+}
+
+func testActors(t *testing.T, r repo.Theatre) {
+	got, err := r.Actors()
+	if err != nil {
+		t.Fatalf("actors: %s", err)
+	}
+	if len(got) != len(Actors) {
+		t.Fatalf("expected %d actors got %d", len(Actors), len(got))
+	}
+	for _, want := range Actors {
+		if got[string(want.Name)] != want.Site {
+			t.Errorf("actor %q: expected site %q got %q", want.Name, want.Site, got[string(want.Name)])
+		}
+	}
+}
+
+func testSessions(t *testing.T, r repo.Theatre) {
+	s, err := r.CurrentSession("climate")
+	if err != nil {
+		t.Fatalf("current session: %s", err)
+	}
+	if s != nil {
+		t.Fatalf("expected no session before start got %d", s.ID)
+	}
+	start := time.Now()
+	if err := r.StartSession("climate", start); err != nil {
+		t.Fatalf("start session: %s", err)
+	}
+	if err := r.StartSession("city", start); err != nil {
+		t.Fatalf("start session: %s", err)
+	}
+	climate, err := r.CurrentSession("climate")
+	if err != nil {
+		t.Fatalf("current session: %s", err)
+	}
+	if climate == nil {
+		t.Fatalf("expected a climate session")
+	}
+	if climate.Theme.Name != "climate" || climate.Theme.Title != "Climate assembly" {
+		t.Errorf("unexpected session theme %+v", climate.Theme)
+	}
+	city, err := r.CurrentSession("city")
+	if err != nil {
+		t.Fatalf("current session: %s", err)
+	}
+	if city == nil || city.ID == climate.ID {
+		t.Fatalf("expected a distinct city session")
+	}
+	if err := r.StopSession("climate", start.Add(time.Minute)); err != nil {
+		t.Fatalf("stop session: %s", err)
+	}
+	s, err = r.CurrentSession("climate")
+	if err != nil {
+		t.Fatalf("current session: %s", err)
+	}
+	if s != nil {
+		t.Errorf("expected no climate session after stop got %d", s.ID)
+	}
+	s, err = r.CurrentSession("city")
+	if err != nil {
+		t.Fatalf("current session: %s", err)
+	}
+	if s == nil || s.ID != city.ID {
+		t.Errorf("expected city session %d to survive climate stop", city.ID)
+	}
+}
+
+func testLocks(t *testing.T, r repo.Theatre) {
+	if err := r.StartSession("climate", time.Now()); err != nil {
+		t.Fatalf("start session: %s", err)
+	}
+	s, err := r.CurrentSession("climate")
+	if err != nil || s == nil {
+		t.Fatalf("current session: %v %v", s, err)
+	}
+	id, err := r.IsActorLocked("alice")
+	if err != nil {
+		t.Fatalf("is actor locked: %s", err)
+	}
+	if id != nil {
+		t.Fatalf("expected alice unlocked got session %d", *id)
+	}
+	if err := r.LockActor("alice", s.ID); err != nil {
+		t.Fatalf("lock actor: %s", err)
+	}
+	if err := r.LockActor("bob", s.ID); err != nil {
+		t.Fatalf("lock actor: %s", err)
+	}
+	id, err = r.IsActorLocked("alice")
+	if err != nil {
+		t.Fatalf("is actor locked: %s", err)
+	}
+	if id == nil || *id != s.ID {
+		t.Fatalf("expected alice locked in session %d got %v", s.ID, id)
+	}
+	s, err = r.CurrentSession("climate")
+	if err != nil {
+		t.Fatalf("current session: %s", err)
+	}
+	if got := names(s.Actors); got != "alice,bob" {
+		t.Errorf("expected session actors alice,bob got %s", got)
+	}
+	unlocked, err := r.UnlockedActors("climate")
+	if err != nil {
+		t.Fatalf("unlocked actors: %s", err)
+	}
+	if got := names(unlocked); got != "carol" {
+		t.Errorf("expected unlocked actors carol got %s", got)
+	}
+	if err := r.UnlockActor("bob", s.ID); err != nil {
+		t.Fatalf("unlock actor: %s", err)
+	}
+	unlocked, err = r.UnlockedActors("climate")
+	if err != nil {
+		t.Fatalf("unlocked actors: %s", err)
+	}
+	if got := names(unlocked); got != "bob,carol" {
+		t.Errorf("expected unlocked actors bob,carol got %s", got)
+	}
+	if err := r.ResetActorLocks(); err != nil {
+		t.Fatalf("reset actor locks: %s", err)
+	}
+	unlocked, err = r.UnlockedActors("climate")
+	if err != nil {
+		t.Fatalf("unlocked actors: %s", err)
+	}
+	if len(unlocked) != len(Actors) {
+		t.Errorf("expected every actor unlocked after reset got %s", names(unlocked))
+	}
+	id, err = r.IsActorLocked("alice")
+	if err != nil {
+		t.Fatalf("is actor locked: %s", err)
+	}
+	if id != nil {
+		t.Errorf("expected alice unlocked after reset got session %d", *id)
+	}
+}
+
+func testTranscript(t *testing.T, r repo.Theatre) {
+	if err := r.StartSession("city", time.Now()); err != nil {
+		t.Fatalf("start session: %s", err)
+	}
+	s, err := r.CurrentSession("city")
+	if err != nil || s == nil {
+		t.Fatalf("current session: %v %v", s, err)
+	}
+	start := time.Now().Truncate(time.Millisecond)
+	chunks := []transcript.Chunk{
+		{Text: "more bike lanes", Timestamp: start, Actor: "alice", Node: "mic-1"},
+		{Text: "and buses at night", Timestamp: start.Add(2 * time.Second), Actor: "alice", Node: "mic-1"},
+		{Text: "who pays for it", Timestamp: start.Add(4 * time.Second), Node: "mic-2"},
+	}
+	// saved out of order, read back by timestamp
+	for _, i := range []int{1, 2, 0} {
+		if err := r.SaveTranscriptChunk(chunks[i], s.ID); err != nil {
+			t.Fatalf("save transcript chunk: %s", err)
+		}
+	}
+	got, err := r.Transcript(s.ID)
+	if err != nil {
+		t.Fatalf("transcript: %s", err)
+	}
+	if len(got) != len(chunks) {
+		t.Fatalf("expected %d chunks got %d", len(chunks), len(got))
+	}
+	for i, c := range chunks {
+		if got[i].Text != c.Text || got[i].Actor != c.Actor || got[i].Node != c.Node ||
+			!got[i].Timestamp.Equal(c.Timestamp) {
+			t.Errorf("chunk %d: expected %+v got %+v", i, c, got[i])
+		}
+	}
+	turns := transcript.Turns(got)
+	if len(turns) != 2 || turns[0].Actor != "alice" || turns[1].Node != "mic-2" {
+		t.Errorf("expected an alice turn then a mic-2 turn got %+v", turns)
+	}
+	other, err := r.Transcript(s.ID + 1)
+	if err != nil {
+		t.Fatalf("transcript: %s", err)
+	}
+	if len(other) != 0 {
+		t.Errorf("expected an empty transcript for an unknown session got %d chunks", len(other))
+	}
+}
+
+func testSearch(t *testing.T, r repo.Theatre) {
+	start := time.Now().Add(-time.Hour)
+	for _, name := range []theme.Name{"climate", "city"} {
+		if err := r.StartSession(name, start); err != nil {
+			t.Fatalf("start session: %s", err)
+		}
+	}
+	climate, err := r.CurrentSession("climate")
+	if err != nil || climate == nil {
+		t.Fatalf("current session: %v %v", climate, err)
+	}
+	city, err := r.CurrentSession("city")
+	if err != nil || city == nil {
+		t.Fatalf("current session: %v %v", city, err)
+	}
+	if err := r.LockActor("alice", climate.ID); err != nil {
+		t.Fatalf("lock actor: %s", err)
+	}
+	for _, c := range []struct {
+		id   session.Id
+		text string
+		at   time.Duration
+	}{
+		{climate.ID, "Solar panels on every school roof", 0},
+		{climate.ID, "wind turbines need less land than solar farms", time.Minute},
+		{city.ID, "solar powered bus shelters", 2 * time.Minute},
+	} {
+		if err := r.SaveTranscriptChunk(transcript.Chunk{
+			Text:      c.text,
+			Timestamp: start.Add(c.at),
+		}, c.id); err != nil {
+			t.Fatalf("save transcript chunk: %s", err)
+		}
+	}
+
+	_, offset := start.Zone()
+	away := time.FixedZone("away", offset+5*3600)
+	for _, tc := range []struct {
+		name     string
+		q        search.Query
+		sessions []session.Id
+	}{
+		{"term", search.Query{Text: "solar"}, []session.Id{climate.ID, climate.ID, city.ID}},
+		{"every term", search.Query{Text: "wind SOLAR"}, []session.Id{climate.ID}},
+		{"theme", search.Query{Text: "solar", Theme: "city"}, []session.Id{city.ID}},
+		{"actor", search.Query{Text: "solar", Actor: "alice"}, []session.Id{climate.ID, climate.ID}},
+		{"from", search.Query{Text: "solar", From: start.Add(30 * time.Second)}, []session.Id{climate.ID, city.ID}},
+		{"to", search.Query{Text: "solar", To: start.Add(30 * time.Second)}, []session.Id{climate.ID}},
+		{"from away", search.Query{Text: "solar", From: start.Add(30 * time.Second).In(away)}, []session.Id{climate.ID, city.ID}},
+		{"to away", search.Query{Text: "solar", To: start.Add(30 * time.Second).In(away)}, []session.Id{climate.ID}},
+		{"no match", search.Query{Text: "nuclear"}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			hits, err := r.Search(tc.q)
+			if err != nil {
+				t.Fatalf("search: %s", err)
+			}
+			got := make([]session.Id, len(hits))
+			for i, hit := range hits {
+				got[i] = hit.Session
+				if !strings.Contains(strings.ToLower(hit.Snippet), search.HighlightStart+"solar"+search.HighlightEnd) &&
+					!strings.Contains(strings.ToLower(hit.Snippet), search.HighlightStart+"nuclear"+search.HighlightEnd) {
+					t.Errorf("expected highlighted snippet got %q", hit.Snippet)
+				}
+			}
+			slices.Sort(got)
+			want := slices.Clone(tc.sessions)
+			slices.Sort(want)
+			if !slices.Equal(got, want) {
+				t.Errorf("expected hits in sessions %v got %v", want, got)
+			}
+		})
+	}
+
+	if _, err := r.Search(search.Query{Text: " ?! "}); err == nil {
+		t.Errorf("expected an error for a query without terms")
+	}
+}
+
+func testNodeBindings(t *testing.T, r repo.Theatre) {
+	bindings, err := r.NodeBindings()
+	if err != nil {
+		t.Fatalf("node bindings: %s", err)
+	}
+	if len(bindings) != 0 {
+		t.Fatalf("expected no bindings got %v", bindings)
+	}
+	for _, b := range []struct {
+		node  node.Name
+		actor actor.Name
+	}{{"mic-1", "alice"}, {"mic-2", "bob"}, {"mic-1", "carol"}} {
+		if err := r.BindNode(b.node, b.actor); err != nil {
+			t.Fatalf("bind node: %s", err)
+		}
+	}
+	bindings, err = r.NodeBindings()
+	if err != nil {
+		t.Fatalf("node bindings: %s", err)
+	}
+	if len(bindings) != 2 || bindings["mic-1"] != "carol" || bindings["mic-2"] != "bob" {
+		t.Errorf("expected mic-1 rebound to carol and mic-2 to bob got %v", bindings)
+	}
+	if err := r.UnbindNode("mic-2"); err != nil {
+		t.Fatalf("unbind node: %s", err)
+	}
+	bindings, err = r.NodeBindings()
+	if err != nil {
+		t.Fatalf("node bindings: %s", err)
+	}
+	if _, ok := bindings["mic-2"]; ok || len(bindings) != 1 {
+		t.Errorf("expected mic-2 unbound got %v", bindings)
+	}
+}
+
+func testImport(t *testing.T, r repo.Theatre) {
+	s, err := r.Session(1)
+	if err != nil {
+		t.Fatalf("session: %s", err)
+	}
+	if s != nil {
+		t.Fatalf("expected no session got %d", s.ID)
+	}
+	start := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	if err := r.StartSession("climate", start); err != nil {
+		t.Fatalf("start session: %s", err)
+	}
+	climate, err := r.CurrentSession("climate")
+	if err != nil || climate == nil {
+		t.Fatalf("current session: %v %v", climate, err)
+	}
+	if err := r.LockActor("alice", climate.ID); err != nil {
+		t.Fatalf("lock actor: %s", err)
+	}
+	// the session keeps its actors once their locks are gone
+	if err := r.ResetActorLocks(); err != nil {
+		t.Fatalf("reset actor locks: %s", err)
+	}
+	s, err = r.Session(climate.ID)
+	if err != nil || s == nil {
+		t.Fatalf("session: %v %v", s, err)
+	}
+	if s.Theme.Title != "Climate assembly" || names(s.Actors) != "alice" ||
+		s.StartAt() == nil || !s.StartAt().Equal(start) || s.EndAt() != nil {
+		t.Errorf("unexpected running session %+v", s)
+	}
+
+	// the archive keeps the offset of the exporting host, another one than
+	// the local time
+	_, offset := start.Zone()
+	away := start.In(time.FixedZone("away", offset+5*3600))
+	imported := session.Session{
+		Theme: theme.Description{
+			Name:       "forest",
+			Title:      "Forest commons",
+			Categories: []theme.Category{{Name: "trees", Keywords: []theme.Keyword{"oak"}}},
+		},
+		Actors: []actor.Description{{Name: "alice", Site: "north"}, {Name: "dave", Site: "west"}},
+		Chunks: []transcript.Chunk{
+			{Text: "who owns the oaks", Timestamp: away, Actor: "dave", Node: "mic-3"},
+			{Text: "the village", Timestamp: start.Add(time.Second), Actor: "alice"},
+		},
+		ID: 42,
+	}
+	imported.Start(away)
+	if _, err := r.ImportSession(imported); err == nil {
+		t.Errorf("expected a session that did not end to be rejected")
+	}
+	imported.End(away.Add(time.Minute))
+	id, err := r.ImportSession(imported)
+	if err != nil {
+		t.Fatalf("import session: %s", err)
+	}
+	if id == climate.ID {
+		t.Fatalf("expected a new id got %d", id)
+	}
+	s, err = r.Session(id)
+	if err != nil || s == nil {
+		t.Fatalf("session: %v %v", s, err)
+	}
+	if s.Theme.Title != "Forest commons" || categories(s.Theme) != "trees:oak" ||
+		names(s.Actors) != "alice,dave" || !s.StartAt().Equal(start) || !s.EndAt().Equal(start.Add(time.Minute)) {
+		t.Errorf("unexpected imported session %+v", s)
+	}
+	actors, err := r.Actors()
+	if err != nil {
+		t.Fatalf("actors: %s", err)
+	}
+	if len(actors) != len(Actors)+1 || actors["dave"] != "west" {
+		t.Errorf("expected dave added to the actors got %v", actors)
+	}
+	chunks, err := r.Transcript(id)
+	if err != nil {
+		t.Fatalf("transcript: %s", err)
+	}
+	if len(chunks) != 2 || chunks[0].Actor != "dave" || chunks[0].Node != "mic-3" || chunks[1].Text != "the village" ||
+		!chunks[0].Timestamp.Equal(start) {
+		t.Errorf("unexpected imported transcript %+v", chunks)
+	}
+	if current, err := r.CurrentSession("forest"); err != nil || current != nil {
+		t.Errorf("expected the imported session to be over got %v %v", current, err)
+	}
+}
+
+func categories(d theme.Description) string {
+	s := make([]string, len(d.Categories))
+	for i, c := range d.Categories {
+		keywords := make([]string, len(c.Keywords))
+		for j, k := range c.Keywords {
+			keywords[j] = string(k)
+		}
+		slices.Sort(keywords)
+		s[i] = c.Name + ":" + strings.Join(keywords, "+")
+	}
+	slices.Sort(s)
+	return strings.Join(s, ",")
+}
+
+func names(actors []actor.Description) string {
+	s := make([]string, len(actors))
+	for i, a := range actors {
+		s[i] = string(a.Name)
+	}
+	slices.Sort(s)
+	return strings.Join(s, ",")
+}
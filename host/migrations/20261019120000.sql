@@ -0,0 +1,28 @@
+-- Give tx a stable rowid for the full-text index
+CREATE TABLE tx_new (
+		id INTEGER PRIMARY KEY,
+		session INTEGER,
+		t8601 TEXT,
+		text TEXT
+);
+INSERT INTO tx_new (session, t8601, text) SELECT session, t8601, text FROM tx;
+DROP TABLE tx;
+ALTER TABLE tx_new RENAME TO tx;
+CREATE INDEX idx_tx_session ON tx(session, t8601);
+-- Create tx_fts full-text index over tx.text
+CREATE VIRTUAL TABLE tx_fts USING fts5(
+		text,
+		content='tx',
+		content_rowid='id'
+);
+CREATE TRIGGER tx_fts_insert AFTER INSERT ON tx BEGIN
+		INSERT INTO tx_fts (rowid, text) VALUES (new.id, new.text);
+END;
+CREATE TRIGGER tx_fts_delete AFTER DELETE ON tx BEGIN
+		INSERT INTO tx_fts (tx_fts, rowid, text) VALUES ('delete', old.id, old.text);
+END;
+CREATE TRIGGER tx_fts_update AFTER UPDATE ON tx BEGIN
+		INSERT INTO tx_fts (tx_fts, rowid, text) VALUES ('delete', old.id, old.text);
+		INSERT INTO tx_fts (rowid, text) VALUES (new.id, new.text);
+END;
+INSERT INTO tx_fts (tx_fts) VALUES ('rebuild');
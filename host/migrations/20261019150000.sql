@@ -0,0 +1,4 @@
+-- Attribute tx chunks to an actor and a recorder node
+ALTER TABLE tx ADD COLUMN actor TEXT;
+ALTER TABLE tx ADD COLUMN node TEXT;
+CREATE INDEX idx_tx_actor ON tx(actor);
@@ -0,0 +1,5 @@
+-- Create nodes_actors table binding recorder nodes to actors
+CREATE TABLE nodes_actors (
+		node TEXT PRIMARY KEY,
+		actor TEXT NOT NULL
+);
@@ -0,0 +1,3 @@
+-- Record the actors of each session, the locks only hold the current ones
+INSERT INTO sessions_actors (actor, session) SELECT actor, session FROM actors_locks;
+CREATE UNIQUE INDEX idx_sessions_actors ON sessions_actors(session, actor);
@@ -26,6 +26,7 @@ type Certifier struct {
 	isCA            bool
 	rsaBits         int
 	certOut, keyOut string
+	clientAuth      bool
 }
 
 func (c Certifier) NewCertificate() (err error) {
@@ -54,6 +55,10 @@ func (c Certifier) NewCertificate() (err error) {
 		IsCA:                  true,
 	}
 
+	if c.clientAuth {
+		template.ExtKeyUsage = append(template.ExtKeyUsage, x509.ExtKeyUsageClientAuth)
+	}
+
 	hosts := strings.Split(c.host, ",")
 	for _, h := range hosts {
 		if ip := net.ParseIP(h); ip != nil {
@@ -125,3 +130,27 @@ func New(host string, opts ...CertifierOption) Certifier {
 	}
 	return c
 }
+
+// OptionOut sets where the certificate and its key are written.
+func OptionOut(certOut, keyOut string) CertifierOption {
+	return func(c Certifier) Certifier {
+		c.certOut, c.keyOut = certOut, keyOut
+		return c
+	}
+}
+
+func OptionValidFor(d time.Duration) CertifierOption {
+	return func(c Certifier) Certifier {
+		c.validFor = d
+		return c
+	}
+}
+
+// OptionClientAuth makes the certificate usable by a client authenticating
+// to a recorder node, the first host is then the client identity.
+func OptionClientAuth() CertifierOption {
+	return func(c Certifier) Certifier {
+		c.clientAuth = true
+		return c
+	}
+}
@@ -0,0 +1,186 @@
+// Package apikey resolves the groq api key from a chain of providers: the
+// environment, the config, the keyring and the key file, sealed with age or
+// in clear.
+package apikey
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// EnvVar is the environment variable holding the key.
+const EnvVar = "GROQ_API_KEY"
+
+var (
+	// ErrNoKey is returned by the providers that do not hold a key.
+	ErrNoKey = errors.New("no api key")
+	// ErrWorldReadable is returned by CheckPermissions.
+	ErrWorldReadable = errors.New("readable by other users")
+)
+
+// Provider is a source of the key.
+type Provider interface {
+	// Name tells where the key comes from, e.g. env GROQ_API_KEY.
+	Name() string
+	// Key returns ErrNoKey when the provider does not hold a key.
+	Key() (string, error)
+}
+
+// Chain returns the key of the first provider holding one.
+type Chain []Provider
+
+func (c Chain) Key() (key string, from Provider, err error) {
+	var names []string
+	for _, p := range c {
+		key, err := p.Key()
+		if errors.Is(err, ErrNoKey) {
+			names = append(names, p.Name())
+			continue
+		}
+		if err != nil {
+			return "", p, fmt.Errorf("%s: %w", p.Name(), err)
+		}
+		return key, p, nil
+	}
+	return "", nil, fmt.Errorf("%w in %s", ErrNoKey, strings.Join(names, ", "))
+}
+
+// Env reads the key from an environment variable, e.g. EnvVar.
+type Env string
+
+func (e Env) Name() string {
+	return "env " + string(e)
+}
+
+func (e Env) Key() (string, error) {
+	key := strings.TrimSpace(os.Getenv(string(e)))
+	if key == "" {
+		return "", ErrNoKey
+	}
+	return key, nil
+}
+
+// Value is a key set in the config.
+type Value struct {
+	Source string
+	Value  string
+}
+
+func (v Value) Name() string {
+	return v.Source
+}
+
+func (v Value) Key() (string, error) {
+	if v.Value == "" {
+		return "", ErrNoKey
+	}
+	return v.Value, nil
+}
+
+// File reads the key from a file, a file ending with .age is decrypted with
+// one of Identities. A file other users may read is logged.
+type File struct {
+	Path       string
+	Identities []age.Identity
+}
+
+func (f File) Name() string {
+	return "file " + f.Path
+}
+
+func (f File) Key() (string, error) {
+	b, err := os.ReadFile(f.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", ErrNoKey
+	}
+	if err != nil {
+		return "", fmt.Errorf("read %q: %w", f.Path, err)
+	}
+	if err := CheckPermissions(f.Path); err != nil {
+		slog.Warn("api key file", "err", err)
+	}
+	if strings.HasSuffix(f.Path, ".age") {
+		if len(f.Identities) == 0 {
+			return "", fmt.Errorf("sealed key %q: no age identity", f.Path)
+		}
+		r, err := age.Decrypt(bytes.NewReader(b), f.Identities...)
+		if err != nil {
+			return "", fmt.Errorf("age decrypt %q: %w", f.Path, err)
+		}
+		if b, err = io.ReadAll(r); err != nil {
+			return "", fmt.Errorf("age decrypt %q: %w", f.Path, err)
+		}
+	}
+	key := strings.TrimSpace(string(b))
+	if key == "" {
+		return "", fmt.Errorf("%q is empty", f.Path)
+	}
+	return key, nil
+}
+
+// CheckPermissions returns ErrWorldReadable when other users may read the
+// file p. The permissions are not checked on windows.
+func CheckPermissions(p string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		return fmt.Errorf("stat %q: %w", p, err)
+	}
+	if perm := info.Mode().Perm(); perm&0004 != 0 {
+		return fmt.Errorf("%q (%s) is %w, chmod 600 it", p, perm, ErrWorldReadable)
+	}
+	return nil
+}
+
+// WriteFile writes key to p readable by its owner only, sealed to
+// recipients when there are some. The key is written to a temporary file
+// created 0600 and renamed to p, it is never readable by other users.
+func WriteFile(p, key string, recipients ...age.Recipient) (err error) {
+	var b bytes.Buffer
+	if len(recipients) == 0 {
+		b.WriteString(key + "\n")
+	} else {
+		w, err := age.Encrypt(&b, recipients...)
+		if err != nil {
+			return fmt.Errorf("age encrypt: %w", err)
+		}
+		if _, err := io.WriteString(w, key); err != nil {
+			return fmt.Errorf("age encrypt: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return fmt.Errorf("age close: %w", err)
+		}
+	}
+	f, err := os.CreateTemp(filepath.Dir(p), "."+filepath.Base(p)+".*")
+	if err != nil {
+		return fmt.Errorf("create temp: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	if _, err = f.Write(b.Bytes()); err != nil {
+		return fmt.Errorf("write %q: %w", f.Name(), err)
+	}
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("close %q: %w", f.Name(), err)
+	}
+	if err = os.Rename(f.Name(), p); err != nil {
+		return fmt.Errorf("rename %q: %w", f.Name(), err)
+	}
+	return nil
+}
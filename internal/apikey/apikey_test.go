@@ -0,0 +1,113 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func TestChain(t *testing.T) {
+	dir := t.TempDir()
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed := File{Path: filepath.Join(dir, "key.age"), Identities: []age.Identity{id}}
+	if err := WriteFile(sealed.Path, "gsk_file", id.Recipient()); err != nil {
+		t.Fatal(err)
+	}
+	keyring := KeyringProvider{FileKeyring{Dir: filepath.Join(dir, "keyring")}}
+	chain := Chain{Env("GROQ_TEST_API_KEY"), Value{Source: "config"}, keyring, sealed}
+
+	for _, step := range []struct {
+		set  func()
+		key  string
+		from string
+	}{
+		{func() {}, "gsk_file", sealed.Name()},
+		{func() {
+			if err := keyring.Set(Account, "gsk_keyring"); err != nil {
+				t.Fatal(err)
+			}
+		}, "gsk_keyring", keyring.Name()},
+		{func() { t.Setenv("GROQ_TEST_API_KEY", "gsk_env") }, "gsk_env", "env GROQ_TEST_API_KEY"},
+	} {
+		step.set()
+		key, from, err := chain.Key()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != step.key || from.Name() != step.from {
+			t.Errorf("got %q from %s, want %q from %s", key, from.Name(), step.key, step.from)
+		}
+	}
+
+	if _, _, err := (Chain{Env("GROQ_TEST_UNSET"), File{Path: filepath.Join(dir, "missing")}}).Key(); !errors.Is(err, ErrNoKey) {
+		t.Errorf("got %v, want %v", err, ErrNoKey)
+	}
+	// a locked keyring is a miss
+	if key, _, err := (Chain{KeyringProvider{lockedKeyring{}}, sealed}).Key(); err != nil || key != "gsk_file" {
+		t.Errorf("got %q, %v past a locked keyring, want the key file", key, err)
+	}
+}
+
+type lockedKeyring struct{}
+
+func (lockedKeyring) Name() string               { return "locked" }
+func (lockedKeyring) Get(string) (string, error) { return "", errors.New("keyring is locked") }
+func (lockedKeyring) Set(string, string) error   { return errors.New("keyring is locked") }
+
+func TestCheckPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the permissions are not checked on windows")
+	}
+	p := filepath.Join(t.TempDir(), "key.txt")
+	if err := WriteFile(p, "gsk"); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckPermissions(p); err != nil {
+		t.Errorf("written key: %v", err)
+	}
+	if err := os.Chmod(p, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckPermissions(p); !errors.Is(err, ErrWorldReadable) {
+		t.Errorf("got %v, want %v", err, ErrWorldReadable)
+	}
+	// the key replaces the readable file
+	if err := WriteFile(p, "gsk"); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckPermissions(p); err != nil {
+		t.Errorf("rewritten key: %v", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	groq := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/v1/models" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "bearer gsk_valid" {
+			http.Error(w, "invalid api key", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"object":"list","data":[]}`))
+	}))
+	defer groq.Close()
+	url := groq.URL + "/openai/v1/audio/transcriptions"
+	if err := Check(context.Background(), groq.Client(), url, "gsk_valid"); err != nil {
+		t.Errorf("valid key: %v", err)
+	}
+	if err := Check(context.Background(), groq.Client(), url, "gsk_revoked"); !errors.Is(err, ErrRejected) {
+		t.Errorf("got %v, want %v", err, ErrRejected)
+	}
+}
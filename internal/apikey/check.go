@@ -0,0 +1,40 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ErrRejected is returned by Check when the provider refuses the key.
+var ErrRejected = errors.New("api key rejected")
+
+// Check validates key listing the models of the openai compatible api of
+// the transcriptions endpoint, e.g. .../openai/v1/models for
+// .../openai/v1/audio/transcriptions.
+func Check(ctx context.Context, client *http.Client, transcriptions, key string) error {
+	u, err := url.Parse(transcriptions)
+	if err != nil {
+		return fmt.Errorf("parse %q: %w", transcriptions, err)
+	}
+	models := u.ResolveReference(&url.URL{Path: "../models"})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, models.String(), nil)
+	if err != nil {
+		return fmt.Errorf("http new request: %w", err)
+	}
+	req.Header.Set("Authorization", "bearer "+key)
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("get %s: %w", models, err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: %s", ErrRejected, resp.Status)
+	}
+	return fmt.Errorf("get %s: %s", models, resp.Status)
+}
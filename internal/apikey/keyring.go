@@ -0,0 +1,128 @@
+package apikey
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// keyringService is the service the key is stored under.
+const keyringService = "groq"
+
+// Account is the keyring account of the api key.
+const Account = "api-key"
+
+// Keyring stores secrets by account.
+type Keyring interface {
+	// Name tells which keyring it is.
+	Name() string
+	// Get returns ErrNoKey when the account has no secret.
+	Get(account string) (string, error)
+	Set(account, secret string) error
+}
+
+// DefaultKeyring is the keyring of the desktop session through secret-tool
+// (libsecret) when there is one, a FileKeyring of the user config dir
+// otherwise.
+func DefaultKeyring() (Keyring, error) {
+	if _, err := exec.LookPath("secret-tool"); err == nil && os.Getenv("DBUS_SESSION_BUS_ADDRESS") != "" {
+		return SecretTool{}, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("user config dir: %w", err)
+	}
+	return FileKeyring{Dir: filepath.Join(dir, "groq", "keyring")}, nil
+}
+
+// SecretTool is the keyring of the desktop session, e.g. gnome-keyring or
+// kwallet.
+type SecretTool struct{}
+
+func (SecretTool) Name() string {
+	return "secret-tool"
+}
+
+func (SecretTool) Get(account string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "lookup", "service", keyringService, "account", account)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	// lookup fails without output when there is no secret
+	if _, ok := err.(*exec.ExitError); ok && stdout.Len() == 0 && stderr.Len() == 0 {
+		return "", ErrNoKey
+	}
+	if err != nil {
+		return "", fmt.Errorf("secret-tool lookup: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	secret := strings.TrimSpace(stdout.String())
+	if secret == "" {
+		return "", ErrNoKey
+	}
+	return secret, nil
+}
+
+func (SecretTool) Set(account, secret string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "store", "--label=groq "+account,
+		"service", keyringService, "account", account)
+	// the secret is read from stdin, never from the arguments
+	cmd.Stdin, cmd.Stderr = strings.NewReader(secret), &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("secret-tool store: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// FileKeyring stores a file per account in Dir, readable by its owner
+// only.
+type FileKeyring struct {
+	Dir string
+}
+
+func (k FileKeyring) Name() string {
+	return "keyring " + k.Dir
+}
+
+func (k FileKeyring) Get(account string) (string, error) {
+	p := filepath.Join(k.Dir, account)
+	b, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", ErrNoKey
+	}
+	if err != nil {
+		return "", fmt.Errorf("read %q: %w", p, err)
+	}
+	if err := CheckPermissions(p); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+func (k FileKeyring) Set(account, secret string) error {
+	if err := os.MkdirAll(k.Dir, 0700); err != nil {
+		return fmt.Errorf("mkdir %q: %w", k.Dir, err)
+	}
+	return WriteFile(filepath.Join(k.Dir, account), secret)
+}
+
+// KeyringProvider reads the key of Account from a keyring. A keyring that
+// fails, e.g. locked, is logged and holds no key so that the chain goes on.
+type KeyringProvider struct {
+	Keyring
+}
+
+func (k KeyringProvider) Key() (string, error) {
+	key, err := k.Get(Account)
+	if err != nil && !errors.Is(err, ErrNoKey) {
+		slog.Warn("api key keyring", "keyring", k.Name(), "err", err)
+		return "", ErrNoKey
+	}
+	return key, err
+}
@@ -0,0 +1,324 @@
+// Package config is the typed configuration of the groq binary.
+//
+// Values are resolved in order: defaults, the yaml file, GROQ_* environment
+// variables and finally the command line flags.
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Sampler   Sampler   `yaml:"sampler"`
+	Encoder   Encoder   `yaml:"encoder"`
+	Serf      Serf      `yaml:"serf"`
+	HTTP      HTTP      `yaml:"http"`
+	Sidecar   Sidecar   `yaml:"sidecar"`
+	Groq      Groq      `yaml:"groq"`
+	Retention Retention `yaml:"retention"`
+}
+
+type Sampler struct {
+	// Rate is the sample rate of the chunks, the input is resampled to it.
+	Rate int `yaml:"rate"`
+	// CaptureRate is the sample rate of the input, the device default rate
+	// when 0.
+	CaptureRate int `yaml:"capture_rate"`
+	// Split is the duration of a chunk.
+	Split Duration `yaml:"split"`
+	// Root is where chunks are written and watched by the sidecar.
+	Root string `yaml:"root"`
+	// Device is the index or name of the input device (groq devices list),
+	// the default input device when empty.
+	Device   string `yaml:"device"`
+	Channels int    `yaml:"channels"`
+	// SplitChannels writes a chunk per channel instead of their downmix.
+	SplitChannels bool `yaml:"split_channels"`
+	// ChannelActors names the actor speaking into each split channel, the
+	// first one into channel 1.
+	ChannelActors []string `yaml:"channel_actors"`
+	// SilenceAfter is how long a track stays quiet before the silence
+	// alarm.
+	SilenceAfter Duration `yaml:"silence_after"`
+	// Backpressure is what the capture does when the encoder falls behind:
+	// block, drop-oldest or spill (to disk).
+	Backpressure string `yaml:"backpressure"`
+}
+
+type Encoder struct {
+	FFmpeg string `yaml:"ffmpeg"`
+	// Sys32 uses the ffmpeg of the windows install (see docs/install.md)
+	// instead of FFmpeg.
+	Sys32 bool `yaml:"sys32"`
+	// Workers is the number of chunks encoded at once.
+	Workers int `yaml:"workers"`
+	// Recipients are the age public keys the chunks are encrypted to, the
+	// raw chunks on disk included, the chunks are in clear when empty. The
+	// transcripts of the sidecar are not encrypted.
+	Recipients []string `yaml:"recipients"`
+}
+
+// DefaultJoin is the serf address of groq-host on its default network.
+const DefaultJoin = "192.168.117.1:7496"
+
+type Serf struct {
+	Port int `yaml:"port"`
+	// Join is the groq-host serf address, empty to only gossip locally or
+	// to discover the swarm. DefaultJoin is ignored when Swarm is set.
+	Join string `yaml:"join"`
+	// Swarm advertises the node over mDNS under this swarm name and joins
+	// the discovered members when Join is empty. groq-host does not take
+	// part in mDNS, it joins the recorders with its --serf-join.
+	Swarm string `yaml:"swarm"`
+	// Name is the node name, the host name when empty.
+	Name  string `yaml:"name"`
+	Actor string `yaml:"actor"`
+	Theme string `yaml:"theme"`
+	// Key is the base64 gossip encryption key (groq swarm keygen), the keys
+	// of KeyringFile win once it exists.
+	Key         string `yaml:"key"`
+	KeyringFile string `yaml:"keyring_file"`
+	// AllowPlaintext lets the node gossip without Key or KeyringFile.
+	AllowPlaintext bool `yaml:"allow_plaintext"`
+}
+
+type HTTP struct {
+	Addr string `yaml:"addr"`
+	// CertFile and KeyFile serve the api over tls, e.g. the files made by
+	// groq-host mkcert.
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ClientCA authenticates control requests with client certificates.
+	ClientCA string `yaml:"client_ca"`
+	// TokensFile authenticates control requests with bearer tokens, one
+	// "identity token" pair per line.
+	TokensFile string `yaml:"tokens_file"`
+}
+
+type Sidecar struct {
+	Host    string `yaml:"host"`
+	HostCA  string `yaml:"host_ca"`
+	Session int    `yaml:"session"`
+	Actor   string `yaml:"actor"`
+	Node    string `yaml:"node"`
+	// NodeAPI is the http api of the recorder node (groq serve) the actor
+	// bound by groq-host is read from, e.g. http://localhost:7495 or an
+	// https url when the node serves tls, trusted with http.cert_file. The
+	// mono chunks fall back to Actor when empty or unreachable.
+	NodeAPI string `yaml:"node_api"`
+	// AckLocal acknowledges the chunks once their transcript is written
+	// locally when there is no Host, retention.delete_acked deletes them
+	// then. Without Host the chunks are never acknowledged otherwise.
+	AckLocal bool `yaml:"ack_local"`
+	// IdentityFile holds the age identities decrypting the chunks sealed
+	// to encoder.recipients.
+	IdentityFile string `yaml:"identity_file"`
+	// Format is the format of the transcript files: text, jsonl or
+	// markdown.
+	Format string `yaml:"format"`
+	// RotateEvery starts a new transcript file every period, e.g. 1h,
+	// never when 0.
+	RotateEvery Duration `yaml:"rotate_every"`
+	// RotateSize starts a new transcript file once it reached this size,
+	// never when 0.
+	RotateSize Size `yaml:"rotate_size"`
+}
+
+// Retention bounds the chunks of sampler.root and the transcripts of its
+// whisper-v3-tx directory, the oldest are deleted first. Zero limits are
+// unbounded.
+type Retention struct {
+	MaxAge   Duration `yaml:"max_age"`
+	MaxSize  Size     `yaml:"max_size"`
+	MaxCount int      `yaml:"max_count"`
+	// TranscriptsMaxAge, TranscriptsMaxSize and TranscriptsMaxCount bound
+	// the transcripts.
+	TranscriptsMaxAge   Duration `yaml:"transcripts_max_age"`
+	TranscriptsMaxSize  Size     `yaml:"transcripts_max_size"`
+	TranscriptsMaxCount int      `yaml:"transcripts_max_count"`
+	// DeleteAcked deletes the audio of a chunk once the sidecar transcribed
+	// it and the host acknowledged the transcript, or once the transcript
+	// is written with sidecar.ack_local.
+	DeleteAcked bool `yaml:"delete_acked"`
+	// Interval is how often serve and sidecar prune, never when 0.
+	Interval Duration `yaml:"interval"`
+}
+
+type Groq struct {
+	URL      string `yaml:"url"`
+	Model    string `yaml:"model"`
+	Language string `yaml:"language"`
+	// Key is the api key, prefer the keyring (groq key set) or KeyFile. The
+	// GROQ_API_KEY environment variable wins over it.
+	Key string `yaml:"key"`
+	// KeyFile holds the api key when neither Key nor the keyring do, it is
+	// sealed with age when it ends with .age.
+	KeyFile string `yaml:"key_file"`
+	// KeyIdentityFile holds the age identities decrypting a sealed
+	// KeyFile.
+	KeyIdentityFile string `yaml:"key_identity_file"`
+	// Concurrency is the number of chunks groq transcribe posts at once.
+	Concurrency int `yaml:"concurrency"`
+	// RequestsPerMinute bounds the requests of groq transcribe, unbounded
+	// when 0.
+	RequestsPerMinute int `yaml:"requests_per_minute"`
+}
+
+func Default() (Config, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return Config{}, fmt.Errorf("user home dir: %w", err)
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return Config{}, fmt.Errorf("hostname: %w", err)
+	}
+	return Config{
+		Sampler: Sampler{
+			Rate:         16000,
+			Split:        Duration(10 * time.Second),
+			Root:         path.Join(home, "groq-whisper-samples"),
+			Channels:     1,
+			SilenceAfter: Duration(30 * time.Second),
+			Backpressure: "spill",
+		},
+		Encoder: Encoder{
+			FFmpeg:  "ffmpeg",
+			Sys32:   true,
+			Workers: 2,
+		},
+		Serf: Serf{
+			Port: 7946,
+			Join: DefaultJoin,
+		},
+		HTTP: HTTP{
+			Addr: ":7495",
+		},
+		Sidecar: Sidecar{
+			Node:   hostname,
+			Format: "text",
+		},
+		Groq: Groq{
+			URL:      "https://api.groq.com/openai/v1/audio/transcriptions",
+			Model:    "whisper-large-v3",
+			Language: "fr",
+			KeyFile:  "key.txt",
+
+			Concurrency:       4,
+			RequestsPerMinute: 20,
+		},
+		Retention: Retention{
+			Interval: Duration(time.Minute),
+		},
+	}, nil
+}
+
+// DefaultPath is the config file read when --config is not set.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("user config dir: %w", err)
+	}
+	return path.Join(dir, "groq", "config.yaml"), nil
+}
+
+// ReadFile decodes the yaml file p over c, unknown keys are rejected.
+func (c *Config) ReadFile(p string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return fmt.Errorf("open %q: %w", p, err)
+	}
+	defer f.Close()
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("decode %q: %w", p, err)
+	}
+	return nil
+}
+
+// Validate reports every invalid value.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	check(c.Sampler.Rate > 0, "sampler.rate: %d is not positive", c.Sampler.Rate)
+	check(c.Sampler.CaptureRate >= 0, "sampler.capture_rate: %d is negative", c.Sampler.CaptureRate)
+	check(time.Duration(c.Sampler.Split) >= time.Second, "sampler.split: %s is shorter than 1s", c.Sampler.Split)
+	check(c.Sampler.Root != "", "sampler.root: empty")
+	check(c.Sampler.Channels > 0, "sampler.channels: %d is not positive", c.Sampler.Channels)
+	check(time.Duration(c.Sampler.SilenceAfter) >= time.Second, "sampler.silence_after: %s is shorter than 1s", c.Sampler.SilenceAfter)
+	check(slices.Contains([]string{"block", "drop-oldest", "spill"}, c.Sampler.Backpressure),
+		"sampler.backpressure: %q is not block, drop-oldest or spill", c.Sampler.Backpressure)
+	check(len(c.Sampler.ChannelActors) <= c.Sampler.Channels,
+		"sampler.channel_actors: %d actors for %d channels", len(c.Sampler.ChannelActors), c.Sampler.Channels)
+	check(c.Encoder.Sys32 || c.Encoder.FFmpeg != "", "encoder.ffmpeg: empty")
+	check(c.Encoder.Workers > 0, "encoder.workers: %d is not positive", c.Encoder.Workers)
+	check(c.Serf.Port > 0 && c.Serf.Port < 1<<16, "serf.port: %d out of range", c.Serf.Port)
+	if c.Serf.Join != "" {
+		_, _, err := net.SplitHostPort(c.Serf.Join)
+		check(err == nil, "serf.join: %v", err)
+	}
+	if c.Serf.Key != "" {
+		b, err := base64.StdEncoding.DecodeString(c.Serf.Key)
+		check(err == nil && (len(b) == 16 || len(b) == 24 || len(b) == 32),
+			"serf.key: expected a base64 key of 16, 24 or 32 bytes")
+	}
+	_, _, err := net.SplitHostPort(c.HTTP.Addr)
+	check(err == nil, "http.addr: %v", err)
+	check((c.HTTP.CertFile == "") == (c.HTTP.KeyFile == ""), "http: cert_file and key_file go together")
+	check(c.HTTP.ClientCA == "" || c.HTTP.CertFile != "", "http.client_ca: requires cert_file")
+	if c.Sidecar.Host != "" {
+		u, err := url.Parse(c.Sidecar.Host)
+		check(err == nil && u.Scheme == "https", "sidecar.host: %q is not an https url", c.Sidecar.Host)
+	}
+	if c.Sidecar.NodeAPI != "" {
+		u, err := url.Parse(c.Sidecar.NodeAPI)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https"),
+			"sidecar.node_api: %q is not an http url", c.Sidecar.NodeAPI)
+	}
+	check(c.Sidecar.Session >= 0, "sidecar.session: %d is negative", c.Sidecar.Session)
+	check(slices.Contains([]string{"text", "jsonl", "markdown"}, c.Sidecar.Format),
+		"sidecar.format: %q is not text, jsonl or markdown", c.Sidecar.Format)
+	check(c.Sidecar.RotateEvery >= 0, "sidecar.rotate_every: %s is negative", c.Sidecar.RotateEvery)
+	check(c.Sidecar.RotateSize >= 0, "sidecar.rotate_size: %s is negative", c.Sidecar.RotateSize)
+	u, err := url.Parse(c.Groq.URL)
+	check(err == nil && u.Scheme != "" && u.Host != "", "groq.url: %q is not an url", c.Groq.URL)
+	check(c.Groq.Model != "", "groq.model: empty")
+	check(len(c.Groq.Language) == 2, "groq.language: %q is not an iso-639-1 code", c.Groq.Language)
+	check(c.Groq.KeyFile != "", "groq.key_file: empty")
+	check(c.Groq.Concurrency > 0, "groq.concurrency: %d is not positive", c.Groq.Concurrency)
+	check(c.Groq.RequestsPerMinute >= 0, "groq.requests_per_minute: %d is negative", c.Groq.RequestsPerMinute)
+	check(c.Retention.MaxAge >= 0 && c.Retention.TranscriptsMaxAge >= 0, "retention: negative max age")
+	check(c.Retention.MaxCount >= 0 && c.Retention.TranscriptsMaxCount >= 0, "retention: negative max count")
+	check(c.Retention.Interval >= 0, "retention.interval: %s is negative", c.Retention.Interval)
+	return errors.Join(errs...)
+}
+
+func (c Config) Marshal() ([]byte, error) {
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func TestLoad(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(p, []byte(`
+sampler:
+  split: 30s
+  channels: 2
+  channel_actors: [alice, bob]
+serf:
+  port: 8000
+  theme: climate
+http:
+  addr: ":9000"
+retention:
+  max_size: 2GiB
+  transcripts_max_size: 500MB
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GROQ_SERF_PORT", "8001")
+	t.Setenv("GROQ_GROQ_LANGUAGE", "en")
+	t.Setenv("GROQ_RETENTION_MAX_AGE", "72h")
+
+	var c Config
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringVar(&c.HTTP.Addr, "http-bind", ":1", "")
+	flags.IntVar(&c.Serf.Port, "serf-port", 1, "")
+	flags.StringSliceVar(&c.Sampler.ChannelActors, "channel-actors", nil, "")
+	if err := flags.Parse([]string{"--http-bind", ":9001", "--channel-actors", "carol,dave"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Load(p, flags); err != nil {
+		t.Fatalf("load: %s", err)
+	}
+	for _, tc := range []struct {
+		name     string
+		got, exp any
+	}{
+		{"default", c.Sampler.Rate, 16000},
+		{"file", c.Sampler.Split, Duration(30 * time.Second)},
+		{"file", c.Serf.Theme, "climate"},
+		{"env over file", c.Serf.Port, 8001},
+		{"env", c.Groq.Language, "en"},
+		{"env duration", c.Retention.MaxAge, Duration(72 * time.Hour)},
+		{"file size", c.Retention.MaxSize, Size(2 << 30)},
+		{"file size", c.Retention.TranscriptsMaxSize, Size(500e6)},
+		{"flag over file", c.HTTP.Addr, ":9001"},
+	} {
+		if tc.got != tc.exp {
+			t.Errorf("%s: expected %v got %v", tc.name, tc.exp, tc.got)
+		}
+	}
+	if got := strings.Join(c.Sampler.ChannelActors, ","); got != "carol,dave" {
+		t.Errorf("slice flag over file: expected carol,dave got %s", got)
+	}
+	if err := c.Validate(); err != nil {
+		t.Errorf("validate: %s", err)
+	}
+}
+
+func TestLoadUnknownKey(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(p, []byte("serf:\n  master: x:1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var c Config
+	if err := c.Load(p, nil); err == nil {
+		t.Error("expected unknown key to be rejected")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("default config: %s", err)
+	}
+	c.Sampler.Rate = 0
+	c.Serf.Join = "no-port"
+	c.Groq.Language = "french"
+	err = c.Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, field := range []string{"sampler.rate", "serf.join", "groq.language"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("expected %s in %q", field, err)
+		}
+	}
+}
+
+func TestEnv(t *testing.T) {
+	names := strings.Join(Env(), " ")
+	for _, name := range []string{"GROQ_SAMPLER_RATE", "GROQ_SIDECAR_HOST_CA", "GROQ_GROQ_KEY_FILE"} {
+		if !strings.Contains(names, name) {
+			t.Errorf("expected %s in %s", name, names)
+		}
+	}
+}
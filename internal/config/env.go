@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix prefixes the environment variables overriding the config, e.g.
+// GROQ_SERF_PORT overrides serf.port.
+const EnvPrefix = "GROQ_"
+
+// Env lists the environment variables c reads, in field order.
+func Env() []string {
+	var names []string
+	walk(reflect.ValueOf(&Config{}).Elem(), func(name string, _ reflect.Value) {
+		names = append(names, name)
+	})
+	return names
+}
+
+// ReadEnv overrides c with the GROQ_* variables that are set.
+func (c *Config) ReadEnv() error {
+	var err error
+	walk(reflect.ValueOf(c).Elem(), func(name string, v reflect.Value) {
+		s, ok := os.LookupEnv(name)
+		if !ok || err != nil {
+			return
+		}
+		if errSet := set(v, s); errSet != nil {
+			err = fmt.Errorf("%s: %w", name, errSet)
+		}
+	})
+	return err
+}
+
+// walk calls fn with the variable name of each leaf field of a config
+// section, sections are the fields of Config.
+func walk(c reflect.Value, fn func(name string, v reflect.Value)) {
+	for i := range c.NumField() {
+		section := c.Field(i)
+		sectionName := yamlName(c.Type().Field(i))
+		for j := range section.NumField() {
+			name := EnvPrefix + strings.ToUpper(sectionName+"_"+yamlName(section.Type().Field(j)))
+			fn(name, section.Field(j))
+		}
+	}
+}
+
+func yamlName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "" {
+		return strings.ToLower(f.Name)
+	}
+	return name
+}
+
+func set(v reflect.Value, s string) error {
+	// Duration and Size parse their own values
+	if setter, ok := v.Addr().Interface().(interface{ Set(string) error }); ok {
+		return setter.Set(s)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice of %s", v.Type().Elem().Kind())
+		}
+		// comma separated values
+		v.Set(reflect.ValueOf(strings.Split(s, ",")))
+	default:
+		return fmt.Errorf("unsupported kind %s", v.Kind())
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/spf13/pflag"
+)
+
+// Load resolves c from the defaults, the file p, the environment and the
+// flags of fs that were set on the command line. Flags of fs are expected
+// to be bound to c fields so that they win over the file and environment.
+// An empty p reads DefaultPath when it exists.
+func (c *Config) Load(p string, flags *pflag.FlagSet) error {
+	set := make(map[string]string)
+	// slice flags are replaced, Set would append to the file values
+	setSlices := make(map[string][]string)
+	if flags != nil {
+		flags.Visit(func(f *pflag.Flag) {
+			if v, ok := f.Value.(pflag.SliceValue); ok {
+				setSlices[f.Name] = v.GetSlice()
+				return
+			}
+			set[f.Name] = f.Value.String()
+		})
+	}
+	conf, err := Default()
+	if err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+	explicit := p != ""
+	if !explicit {
+		if p, err = DefaultPath(); err != nil {
+			return err
+		}
+	}
+	if err := conf.ReadFile(p); err != nil {
+		if explicit || !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	if err := conf.ReadEnv(); err != nil {
+		return fmt.Errorf("env: %w", err)
+	}
+	*c = conf
+	for name, value := range set {
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("flag --%s: %w", name, err)
+		}
+	}
+	for name, values := range setSlices {
+		if err := flags.Lookup(name).Value.(pflag.SliceValue).Replace(values); err != nil {
+			return fmt.Errorf("flag --%s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Size is a number of bytes written as "500MB" or "2GiB" in yaml files and
+// flags.
+type Size int64
+
+var sizeUnits = []struct {
+	suffix string
+	n      int64
+}{
+	{"TiB", 1 << 40}, {"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10},
+	{"TB", 1e12}, {"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3},
+	{"B", 1},
+}
+
+func (s Size) String() string {
+	for _, u := range sizeUnits[:4] {
+		if s != 0 && int64(s)%u.n == 0 {
+			return fmt.Sprintf("%d%s", int64(s)/u.n, u.suffix)
+		}
+	}
+	return strconv.FormatInt(int64(s), 10)
+}
+
+func (s *Size) Set(v string) error {
+	n, unit := strings.TrimSpace(v), int64(1)
+	for _, u := range sizeUnits {
+		if trimmed, ok := strings.CutSuffix(n, u.suffix); ok {
+			n, unit = strings.TrimSpace(trimmed), u.n
+			break
+		}
+	}
+	i, err := strconv.ParseInt(n, 10, 64)
+	if err != nil || i < 0 {
+		return fmt.Errorf("parse size %q: expected e.g. 500MB or 2GiB", v)
+	}
+	*s = Size(i * unit)
+	return nil
+}
+
+func (s Size) Type() string {
+	return "size"
+}
+
+func (s Size) MarshalYAML() (any, error) {
+	return s.String(), nil
+}
+
+func (s *Size) UnmarshalYAML(n *yaml.Node) error {
+	return s.Set(n.Value)
+}
@@ -0,0 +1,323 @@
+// Package discovery advertises and finds recorder nodes on the LAN with
+// mDNS/DNS-SD so that they can join a swarm without a known master.
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Service is the DNS-SD service type of the serf port of recorder nodes.
+const Service = "_groq-serf._udp.local."
+
+// MulticastAddr is the mDNS group.
+const MulticastAddr = "224.0.0.251:5353"
+
+const ttl = 120
+
+// Peer is a node advertising the serf port of a swarm.
+type Peer struct {
+	Node string
+	Addr string
+}
+
+type Config struct {
+	addr    string
+	ips     []net.IP
+	timeout time.Duration
+}
+
+type Option func(Config) Config
+
+func defaultConfig() Config {
+	return Config{
+		addr:    MulticastAddr,
+		timeout: 2 * time.Second,
+	}
+}
+
+// OptionAddr replaces the mDNS group, a unicast address (e.g. loopback)
+// makes the responder listen and Lookup query that address only.
+func OptionAddr(addr string) Option {
+	return func(c Config) Config {
+		c.addr = addr
+		return c
+	}
+}
+
+// OptionIPs sets the addresses advertised by a responder, the non loopback
+// IPv4 addresses of the host by default. Without addresses peers use the
+// source of the answer.
+func OptionIPs(ips ...net.IP) Option {
+	return func(c Config) Config {
+		c.ips = append([]net.IP{}, ips...)
+		return c
+	}
+}
+
+// OptionTimeout bounds how long Lookup collects answers.
+func OptionTimeout(d time.Duration) Option {
+	return func(c Config) Config {
+		c.timeout = d
+		return c
+	}
+}
+
+// Responder answers DNS-SD queries for Service on behalf of one node.
+type Responder struct {
+	conn  *net.UDPConn
+	node  string
+	swarm string
+	port  int
+	ips   []net.IP
+}
+
+// NewResponder advertises the serf port of node as a member of swarm.
+func NewResponder(node, swarm string, port int, opts ...Option) (*Responder, error) {
+	conf := defaultConfig()
+	for _, opt := range opts {
+		conf = opt(conf)
+	}
+	addr, err := net.ResolveUDPAddr("udp4", conf.addr)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %q: %w", conf.addr, err)
+	}
+	var conn *net.UDPConn
+	if addr.IP.IsMulticast() {
+		conn, err = net.ListenMulticastUDP("udp4", nil, addr)
+	} else {
+		conn, err = net.ListenUDP("udp4", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listen %q: %w", conf.addr, err)
+	}
+	ips := conf.ips
+	if ips == nil {
+		ips = localIPs()
+	}
+	return &Responder{
+		conn:  conn,
+		node:  node,
+		swarm: swarm,
+		port:  port,
+		ips:   ips,
+	}, nil
+}
+
+// Addr is the address the responder listens on.
+func (r *Responder) Addr() net.Addr {
+	return r.conn.LocalAddr()
+}
+
+// Serve answers queries until ctx is done.
+func (r *Responder) Serve(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		r.conn.Close()
+	}()
+	b := make([]byte, 9000)
+	for {
+		n, from, err := r.conn.ReadFromUDP(b)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("read: %w", err)
+		}
+		var q dns.Msg
+		if err := q.Unpack(b[:n]); err != nil || q.Response {
+			continue
+		}
+		if !asksService(&q) {
+			continue
+		}
+		res, err := r.answer(&q).Pack()
+		if err != nil {
+			slog.Warn("mdns: pack answer", "err", err)
+			continue
+		}
+		// queries from an ephemeral port get a unicast answer (rfc 6762 6.7)
+		if _, err := r.conn.WriteToUDP(res, from); err != nil {
+			slog.Warn("mdns: write answer", "to", from, "err", err)
+		}
+	}
+}
+
+func asksService(q *dns.Msg) bool {
+	for _, question := range q.Question {
+		if question.Qtype == dns.TypePTR && strings.EqualFold(question.Name, Service) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Responder) answer(q *dns.Msg) *dns.Msg {
+	instance := dns.Fqdn(label(r.node) + "." + Service)
+	target := dns.Fqdn(label(r.node) + ".local")
+	m := new(dns.Msg)
+	m.SetReply(q)
+	m.Authoritative = true
+	m.Answer = []dns.RR{&dns.PTR{
+		Hdr: header(Service, dns.TypePTR),
+		Ptr: instance,
+	}}
+	m.Extra = []dns.RR{
+		&dns.SRV{
+			Hdr:    header(instance, dns.TypeSRV),
+			Port:   uint16(r.port),
+			Target: target,
+		},
+		&dns.TXT{
+			Hdr: header(instance, dns.TypeTXT),
+			Txt: []string{"swarm=" + r.swarm, "node=" + r.node},
+		},
+	}
+	for _, ip := range r.ips {
+		m.Extra = append(m.Extra, &dns.A{Hdr: header(target, dns.TypeA), A: ip})
+	}
+	return m
+}
+
+func header(name string, rrtype uint16) dns.RR_Header {
+	return dns.RR_Header{Name: name, Rrtype: rrtype, Class: dns.ClassINET, Ttl: ttl}
+}
+
+// label makes a node name usable as a single dns label.
+func label(node string) string {
+	return strings.NewReplacer(".", "-", " ", "-").Replace(node)
+}
+
+// Lookup queries Service and returns the peers advertising swarm, in the
+// order they answered.
+func Lookup(ctx context.Context, swarm string, opts ...Option) ([]Peer, error) {
+	conf := defaultConfig()
+	for _, opt := range opts {
+		conf = opt(conf)
+	}
+	to, err := net.ResolveUDPAddr("udp4", conf.addr)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %q: %w", conf.addr, err)
+	}
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, fmt.Errorf("listen: %w", err)
+	}
+	defer conn.Close()
+
+	q := new(dns.Msg)
+	q.SetQuestion(Service, dns.TypePTR)
+	b, err := q.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("pack query: %w", err)
+	}
+	if _, err := conn.WriteToUDP(b, to); err != nil {
+		return nil, fmt.Errorf("write query: %w", err)
+	}
+
+	deadline := time.Now().Add(conf.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("set read deadline: %w", err)
+	}
+	peers := []Peer{}
+	seen := make(map[string]bool)
+	buf := make([]byte, 9000)
+	for ctx.Err() == nil {
+		n, from, err := conn.ReadFromUDP(buf)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			break
+		}
+		if err != nil {
+			return peers, fmt.Errorf("read: %w", err)
+		}
+		var res dns.Msg
+		if err := res.Unpack(buf[:n]); err != nil || !res.Response {
+			continue
+		}
+		for _, p := range parse(&res, swarm, from.IP) {
+			if !seen[p.Node] {
+				seen[p.Node] = true
+				peers = append(peers, p)
+			}
+		}
+	}
+	return peers, nil
+}
+
+// parse reads the peers of swarm from an answer, from is used when the
+// answer has no address record.
+func parse(m *dns.Msg, swarm string, from net.IP) []Peer {
+	type instance struct {
+		port   int
+		target string
+		txt    map[string]string
+	}
+	instances := make(map[string]*instance)
+	get := func(name string) *instance {
+		if instances[name] == nil {
+			instances[name] = &instance{txt: make(map[string]string)}
+		}
+		return instances[name]
+	}
+	addrs := make(map[string]net.IP)
+	for _, rr := range append(m.Answer, m.Extra...) {
+		switch rr := rr.(type) {
+		case *dns.SRV:
+			i := get(rr.Hdr.Name)
+			i.port, i.target = int(rr.Port), rr.Target
+		case *dns.TXT:
+			i := get(rr.Hdr.Name)
+			for _, kv := range rr.Txt {
+				k, v, _ := strings.Cut(kv, "=")
+				i.txt[k] = v
+			}
+		case *dns.A:
+			if _, ok := addrs[rr.Hdr.Name]; !ok {
+				addrs[rr.Hdr.Name] = rr.A
+			}
+		}
+	}
+	peers := []Peer{}
+	for _, i := range instances {
+		if i.port == 0 || i.txt["swarm"] != swarm {
+			continue
+		}
+		ip, ok := addrs[i.target]
+		if !ok {
+			ip = from
+		}
+		peers = append(peers, Peer{
+			Node: i.txt["node"],
+			Addr: net.JoinHostPort(ip.String(), strconv.Itoa(i.port)),
+		})
+	}
+	return peers
+}
+
+func localIPs() []net.IP {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	ips := []net.IP{}
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() {
+			if ip := ipnet.IP.To4(); ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+	}
+	return ips
+}
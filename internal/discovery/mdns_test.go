@@ -0,0 +1,75 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// responder listens on loopback only, the tests never touch the LAN.
+func responder(t *testing.T, node, swarm string, port int) *Responder {
+	t.Helper()
+	r, err := NewResponder(node, swarm, port,
+		OptionAddr("127.0.0.1:0"),
+		OptionIPs(net.IPv4(127, 0, 0, 1)))
+	if err != nil {
+		t.Fatalf("new responder: %s", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- r.Serve(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("serve: %s", err)
+		}
+	})
+	return r
+}
+
+func TestLookup(t *testing.T) {
+	r := responder(t, "studio.a", "climate", 7946)
+	for _, tc := range []struct {
+		swarm string
+		peers []Peer
+	}{
+		{"climate", []Peer{{Node: "studio.a", Addr: "127.0.0.1:7946"}}},
+		{"city", []Peer{}},
+	} {
+		peers, err := Lookup(context.Background(), tc.swarm,
+			OptionAddr(r.Addr().String()),
+			OptionTimeout(300*time.Millisecond))
+		if err != nil {
+			t.Fatalf("lookup %s: %s", tc.swarm, err)
+		}
+		if len(peers) != len(tc.peers) {
+			t.Fatalf("lookup %s: expected %v got %v", tc.swarm, tc.peers, peers)
+		}
+		for i := range peers {
+			if peers[i] != tc.peers[i] {
+				t.Errorf("lookup %s: expected %v got %v", tc.swarm, tc.peers[i], peers[i])
+			}
+		}
+	}
+}
+
+func TestLookupSourceAddr(t *testing.T) {
+	r, err := NewResponder("studio-b", "climate", 8000,
+		OptionAddr("127.0.0.1:0"), OptionIPs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Serve(ctx)
+	peers, err := Lookup(ctx, "climate",
+		OptionAddr(r.Addr().String()),
+		OptionTimeout(300*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 1 || peers[0].Addr != "127.0.0.1:8000" {
+		t.Errorf("expected the answer source address got %v", peers)
+	}
+}
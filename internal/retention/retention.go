@@ -0,0 +1,310 @@
+// Package retention bounds the chunks the sampler leaves in the samples root
+// and the transcripts the sidecar writes next to them.
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TranscriptsDir is the directory of the samples root holding the
+// transcripts of the sidecar.
+const TranscriptsDir = "whisper-v3-tx"
+
+// Kind is what an entry holds.
+type Kind int
+
+const (
+	KindAudio Kind = iota
+	KindTranscript
+)
+
+var kinds = []string{"audio", "transcript"}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kinds) {
+		return fmt.Sprintf("Kind(%d)", k)
+	}
+	return kinds[k]
+}
+
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Entry is a chunk with its audio files, or a transcript. Entries are
+// deleted whole.
+type Entry struct {
+	// Name is the chunk name without extension, e.g. 20060102150405,000.ch1,
+	// or the transcript file name.
+	Name string    `json:"name"`
+	Kind Kind      `json:"kind"`
+	Time time.Time `json:"time"`
+	Size int64     `json:"size"`
+	// Acked chunks were transcribed and acknowledged by the host (see Ack).
+	Acked bool     `json:"acked"`
+	Files []string `json:"files"`
+	// modified is the last modification of the files.
+	modified time.Time
+	// encoded chunks have a flac file, sealed or not.
+	encoded bool
+}
+
+// Limits bound the entries of a kind, zero limits are unbounded.
+type Limits struct {
+	MaxAge   time.Duration
+	MaxSize  int64
+	MaxCount int
+}
+
+// Policy is what Prune deletes, the oldest entries first.
+type Policy struct {
+	Audio       Limits
+	Transcripts Limits
+	// DeleteAcked deletes the chunks once acknowledged, whatever the
+	// limits.
+	DeleteAcked bool
+}
+
+// grace keeps the entries modified lately out of the limits, the sampler
+// may still be encoding them or the sidecar writing them.
+const grace = time.Minute
+
+// audioExts are the files of a chunk, ack is the acknowledgment marker.
+var audioExts = []string{"raw", "mp3", "flac", "ack"}
+
+// transcriptExts are the extensions of the sidecar transcript formats.
+var transcriptExts = []string{"txt", "jsonl", "md"}
+
+// Scan lists the chunks of root and the transcripts of its TranscriptsDir,
+// the oldest first. Files not named by the sampler or the sidecar are
+// ignored.
+func Scan(root string) ([]Entry, error) {
+	dirents, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("read dir %q: %w", root, err)
+	}
+	chunks := make(map[string]*Entry)
+	for _, d := range dirents {
+		stem, ext, ok := chunkStem(d.Name())
+		if !d.Type().IsRegular() || !ok || !slices.Contains(audioExts, ext) {
+			continue
+		}
+		ts, ok := chunkTime(stem)
+		if !ok {
+			continue
+		}
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("stat %q: %w", d.Name(), err)
+		}
+		e, ok := chunks[stem]
+		if !ok {
+			e = &Entry{Name: stem, Kind: KindAudio, Time: ts}
+			chunks[stem] = e
+		}
+		e.Files = append(e.Files, filepath.Join(root, d.Name()))
+		e.Size += info.Size()
+		e.Acked = e.Acked || ext == "ack"
+		e.encoded = e.encoded || ext == "flac"
+		if info.ModTime().After(e.modified) {
+			e.modified = info.ModTime()
+		}
+	}
+	entries := make([]Entry, 0, len(chunks))
+	for _, e := range chunks {
+		entries = append(entries, *e)
+	}
+
+	transcripts, err := scanTranscripts(filepath.Join(root, TranscriptsDir))
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, transcripts...)
+	slices.SortFunc(entries, func(a, b Entry) int {
+		if c := a.Time.Compare(b.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return entries, nil
+}
+
+func scanTranscripts(dir string) ([]Entry, error) {
+	dirents, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read dir %q: %w", dir, err)
+	}
+	var entries []Entry
+	for _, d := range dirents {
+		stem, ext, ok := cutExt(d.Name())
+		if !d.Type().IsRegular() || !ok || !slices.Contains(transcriptExts, ext) {
+			continue
+		}
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("stat %q: %w", d.Name(), err)
+		}
+		// the sidecar names the transcript after its start
+		ts, err := time.ParseInLocation("2006-01-02_15-04-05.000", stem, time.Local)
+		if err != nil {
+			ts = info.ModTime()
+		}
+		entries = append(entries, Entry{
+			Name:     d.Name(),
+			Kind:     KindTranscript,
+			Time:     ts,
+			Size:     info.Size(),
+			Files:    []string{filepath.Join(dir, d.Name())},
+			modified: info.ModTime(),
+		})
+	}
+	return entries, nil
+}
+
+func cutExt(name string) (stem, ext string, ok bool) {
+	i := strings.LastIndexByte(name, '.')
+	if i <= 0 {
+		return "", "", false
+	}
+	return name[:i], name[i+1:], true
+}
+
+// chunkStem cuts the extension of a chunk file name, the .age suffix of
+// sealed chunks included.
+func chunkStem(name string) (stem, ext string, ok bool) {
+	return cutExt(strings.TrimSuffix(name, ".age"))
+}
+
+// chunkTime parses the timestamp the sampler starts chunk names with, the
+// milliseconds after the comma included.
+func chunkTime(stem string) (time.Time, bool) {
+	ts, _, _ := strings.Cut(stem, ".")
+	t, err := time.ParseInLocation("20060102150405", ts, time.Local)
+	return t, err == nil
+}
+
+// Plan returns the entries p deletes at now, the oldest first.
+func (p Policy) Plan(entries []Entry, now time.Time) []Entry {
+	var expired []Entry
+	for kind, l := range []Limits{KindAudio: p.Audio, KindTranscript: p.Transcripts} {
+		var (
+			kept []Entry
+			size int64
+		)
+		for _, e := range entries {
+			switch {
+			case e.Kind != Kind(kind):
+			case e.Kind == KindAudio && p.DeleteAcked && e.Acked:
+				expired = append(expired, e)
+			case now.Sub(e.modified) >= grace && l.MaxAge > 0 && now.Sub(e.Time) > l.MaxAge:
+				expired = append(expired, e)
+			case e.Kind == KindAudio && !e.encoded:
+				// a raw chunk spilled under backpressure may wait for the
+				// encoder longer than grace, it is not the oldest to go
+			default:
+				kept = append(kept, e)
+				size += e.Size
+			}
+		}
+		n := len(kept)
+		for _, e := range kept {
+			if (l.MaxCount == 0 || n <= l.MaxCount) && (l.MaxSize == 0 || size <= l.MaxSize) {
+				break
+			}
+			if now.Sub(e.modified) < grace {
+				continue
+			}
+			expired = append(expired, e)
+			n--
+			size -= e.Size
+		}
+	}
+	slices.SortStableFunc(expired, func(a, b Entry) int {
+		return a.Time.Compare(b.Time)
+	})
+	return expired
+}
+
+// Remove deletes the files of e, the ones already gone are skipped.
+func Remove(e Entry) error {
+	var errs []error
+	for _, f := range e.Files {
+		if err := os.Remove(f); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Prune deletes the entries of root p plans at now and returns them. An
+// entry failing to be removed does not stop the next ones.
+func Prune(root string, p Policy, now time.Time) (removed []Entry, err error) {
+	entries, err := Scan(root)
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	for _, e := range p.Plan(entries, now) {
+		if err := Remove(e); err != nil {
+			errs = append(errs, fmt.Errorf("remove %s: %w", e.Name, err))
+			continue
+		}
+		removed = append(removed, e)
+	}
+	return removed, errors.Join(errs...)
+}
+
+// Run prunes root every interval until ctx is done.
+func Run(ctx context.Context, root string, p Policy, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		removed, err := Prune(root, p, time.Now())
+		if err != nil {
+			slog.Warn("retention: prune", "root", root, "err", err)
+		}
+		if len(removed) > 0 {
+			var size int64
+			for _, e := range removed {
+				size += e.Size
+			}
+			slog.Info("retention: pruned", "root", root, "entries", len(removed), "bytes", size)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Ack marks the chunk of the audio file name as transcribed and
+// acknowledged, DeleteAcked policies delete it.
+func Ack(name string) error {
+	stem, _, ok := chunkStem(name)
+	if !ok {
+		return fmt.Errorf("ack %q: not a chunk", name)
+	}
+	if err := os.WriteFile(stem+".ack", nil, 0600); err != nil {
+		return fmt.Errorf("ack %q: %w", name, err)
+	}
+	return nil
+}
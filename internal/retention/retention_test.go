@@ -0,0 +1,85 @@
+package retention
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPrune(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+	write := func(name string, size int, age time.Duration) {
+		t.Helper()
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, make([]byte, size), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chunk := func(age time.Duration) string {
+		return now.Add(-age).Format("20060102150405,000")
+	}
+	write(chunk(3*time.Hour)+".flac", 100, 3*time.Hour)
+	write(chunk(3*time.Hour)+".mp3", 100, 3*time.Hour)
+	write(chunk(2*time.Hour)+".ch1.flac", 100, 2*time.Hour)
+	write(chunk(time.Hour)+".ch1.flac", 100, time.Hour)
+	write(chunk(time.Hour)+".ch2.flac", 100, time.Hour)
+	write(chunk(time.Hour)+".ch2.ack", 0, time.Hour)
+	// still encoding
+	write(chunk(0)+".raw", 100, 0)
+	// spilled, waiting for the encoder past the grace
+	write(chunk(5*time.Minute)+".raw.age", 100, 5*time.Minute)
+	write("notes.flac", 100, 5*time.Hour)
+	tx := now.Add(-48 * time.Hour).Format("2006-01-02_15-04-05.000.txt")
+	write(filepath.Join(TranscriptsDir, tx), 10, 24*time.Hour)
+
+	entries, err := Scan(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 7 {
+		t.Fatalf("scanned %d entries, want 7: %v", len(entries), entries)
+	}
+	if e := entries[1]; e.Kind != KindAudio || e.Size != 200 || len(e.Files) != 2 {
+		t.Errorf("got %s %s of %d bytes in %d files, want the flac and mp3 chunk",
+			e.Kind, e.Name, e.Size, len(e.Files))
+	}
+
+	p := Policy{
+		Audio:       Limits{MaxAge: 150 * time.Minute, MaxCount: 1},
+		Transcripts: Limits{MaxAge: 36 * time.Hour},
+		DeleteAcked: true,
+	}
+	removed, err := Prune(root, p, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range removed {
+		names = append(names, e.Name)
+	}
+	want := []string{
+		tx,                          // max age
+		chunk(3 * time.Hour),        // max age
+		chunk(2*time.Hour) + ".ch1", // max count
+		chunk(time.Hour) + ".ch2",   // acked
+	}
+	if !slices.Equal(names, want) {
+		t.Errorf("removed %v, want %v", names, want)
+	}
+	entries, err = Scan(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("kept %v, want the last chunk and the raw ones", entries)
+	}
+}
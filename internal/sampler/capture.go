@@ -0,0 +1,136 @@
+package sampler
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+// capture turns the interleaved input buffers into the chunks of each
+// track, their samples go to the buffers of the ring so that the steady
+// state does not allocate.
+type capture struct {
+	s    sampler
+	ring *ring
+	// tracks are the chunks being filled, a track per channel when split,
+	// the downmix otherwise.
+	tracks     [][]byte
+	frame      []int16
+	resampled  []int16
+	meters     []*meter
+	resamplers []*resampler
+
+	// last is the cut of the previous chunks.
+	last time.Time
+}
+
+func (s sampler) newCapture(rate float64, r *ring) *capture {
+	c := &capture{
+		s:          s,
+		ring:       r,
+		tracks:     make([][]byte, s.tracks()),
+		frame:      make([]int16, framesPerBuffer),
+		resampled:  make([]int16, 0, int(math.Ceil(framesPerBuffer*s.sample/rate))+1),
+		meters:     make([]*meter, s.tracks()),
+		resamplers: make([]*resampler, s.tracks()),
+	}
+	for i := range c.tracks {
+		channel := 0
+		if s.split {
+			channel = i + 1
+		}
+		c.tracks[i] = r.get()
+		c.meters[i] = newMeter(channel, rate, s.silence)
+		c.resamplers[i] = newResampler(rate, s.sample)
+	}
+	return c
+}
+
+// tracks is the number of chunks of each split.
+func (s sampler) tracks() int {
+	if s.split {
+		return s.channels
+	}
+	return 1
+}
+
+// chunkSize is the size of a chunk buffer, it holds a split period and the
+// samples of a late cut.
+func (s sampler) chunkSize() int {
+	return 2 * int(math.Ceil(s.splitFreq.Seconds()*s.sample*1.1))
+}
+
+// write processes a buffer of interleaved frames, the chunks are cut
+// early when a buffer is full.
+func (c *capture) write(in []int16, now time.Time) {
+	for i := range c.tracks {
+		if c.s.split {
+			deinterleave(c.frame, in, c.s.channels, i)
+		} else {
+			downmix(c.frame, in, c.s.channels)
+		}
+		c.resampled = c.resamplers[i].process(c.resampled[:0], c.frame)
+		if len(c.tracks[i])+2*len(c.resampled) > cap(c.tracks[i]) {
+			c.cut(now)
+		}
+		c.tracks[i] = appendSamples(c.tracks[i], c.resampled)
+		for _, kind := range c.meters[i].add(c.frame, now) {
+			ev := c.s.chunkEvent(kind, chunk{channel: c.meters[i].level.Channel}, "", nil)
+			ev.Level = c.meters[i].level
+			c.s.events.publish(ev)
+		}
+	}
+	c.s.stats.level(rms(in))
+	c.s.stats.levels(c.meters)
+}
+
+// cut queues the chunks ended at ts and starts the next ones.
+func (c *capture) cut(ts time.Time) {
+	ts = c.stamp(ts)
+	for i := range c.tracks {
+		if len(c.tracks[i]) == 0 {
+			continue
+		}
+		ch := chunk{raw: c.tracks[i], ts: ts}
+		if c.s.split {
+			ch.channel = i + 1
+		}
+		c.ring.put(ch)
+		c.tracks[i] = c.ring.get()
+		c.meters[i].endChunk()
+	}
+	c.s.stats.levels(c.meters)
+}
+
+// stamp truncates ts to the millisecond of the chunk names, a cut within
+// the millisecond of the previous one is moved after it so that no chunk
+// overwrites another.
+func (c *capture) stamp(ts time.Time) time.Time {
+	ts = ts.Truncate(time.Millisecond)
+	if !ts.After(c.last) {
+		ts = c.last.Add(time.Millisecond)
+	}
+	c.last = ts
+	return ts
+}
+
+// flush queues the partial chunks, the buffers left are released.
+func (c *capture) flush(ts time.Time) {
+	for i := range c.tracks {
+		c.resampled = c.resamplers[i].flush(c.resampled[:0])
+		c.tracks[i] = appendSamples(c.tracks[i], c.resampled)
+	}
+	c.cut(ts)
+	for i := range c.tracks {
+		c.ring.release(chunk{raw: c.tracks[i]})
+		c.tracks[i] = nil
+	}
+}
+
+// appendSamples appends the little endian samples to b.
+func appendSamples(b []byte, samples []int16) []byte {
+	for _, v := range samples {
+		b = binary.LittleEndian.AppendUint16(b, uint16(v))
+	}
+	return b
+}
@@ -0,0 +1,76 @@
+package sampler
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestCapture captures a 48 kHz mono input to 1s chunks at 16 kHz, the
+// chunks are released as soon as they are queued.
+func newTestCapture() (*capture, func()) {
+	s := New(16000, time.Second).(*sampler)
+	r := newRing(s.tracks(), s.chunkSize(), PolicyBlock, nil, s.stats)
+	go func() {
+		for {
+			c, ok := r.take()
+			if !ok {
+				return
+			}
+			r.release(c)
+		}
+	}()
+	return s.newCapture(48000, r), r.close
+}
+
+// buffersPerSecond is the number of 48 kHz input buffers of a second.
+const buffersPerSecond = 48000 / framesPerBuffer
+
+func TestCaptureAllocs(t *testing.T) {
+	c, stop := newTestCapture()
+	defer stop()
+	in := tone(440, 0.5, 48000, float64(framesPerBuffer)/48000)
+	now := time.Now()
+	second := func() {
+		for range buffersPerSecond {
+			c.write(in, now)
+		}
+		c.cut(now)
+	}
+	// warm up the resamplers and the stats
+	second()
+	if allocs := testing.AllocsPerRun(5, second); allocs != 0 {
+		t.Errorf("%.0f allocations per second of capture", allocs)
+	}
+}
+
+func BenchmarkCapture(b *testing.B) {
+	c, stop := newTestCapture()
+	defer stop()
+	in := tone(440, 0.5, 48000, float64(framesPerBuffer)/48000)
+	now := time.Now()
+	b.ReportAllocs()
+	for i := range b.N {
+		c.write(in, now)
+		if i%buffersPerSecond == buffersPerSecond-1 {
+			c.cut(now)
+		}
+	}
+}
+
+func TestCaptureStamp(t *testing.T) {
+	c, stop := newTestCapture()
+	defer stop()
+	e := NewEncoder()
+	now := time.Date(2026, 10, 19, 14, 58, 0, 1_500_000, time.Local)
+	seen := map[string]bool{}
+	for range 3 {
+		p := e.outPath(c.stamp(now), 1, outFlac)
+		if seen[p] {
+			t.Fatalf("chunk %q cut twice", p)
+		}
+		seen[p] = true
+	}
+	if got, want := e.outPath(c.stamp(now.Add(time.Second)), 0, outFlac), "20261019145801,001.flac"; got != want {
+		t.Errorf("outPath = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,158 @@
+package sampler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gordonklaus/portaudio"
+)
+
+// Device is an audio input device.
+type Device struct {
+	Index    int    `json:"index"`
+	Name     string `json:"name"`
+	HostAPI  string `json:"host_api"`
+	Channels int    `json:"channels"`
+	// Rates are the sample rates of probeRates the device supports.
+	Rates       []float64 `json:"rates"`
+	DefaultRate float64   `json:"default_rate"`
+	Default     bool      `json:"default"`
+}
+
+// probeRates are the sample rates Devices checks.
+var probeRates = []float64{8000, 11025, 16000, 22050, 32000, 44100, 48000, 96000}
+
+// Devices lists the input devices.
+func Devices() (devices []Device, err error) {
+	if err := portaudio.Initialize(); err != nil {
+		return nil, fmt.Errorf("portaudio initialize: %w", err)
+	}
+	defer func() {
+		if errTerminate := portaudio.Terminate(); err == nil && errTerminate != nil {
+			err = fmt.Errorf("portaudio terminate: %w", errTerminate)
+		}
+	}()
+	infos, err := portaudio.Devices()
+	if err != nil {
+		return nil, fmt.Errorf("portaudio devices: %w", err)
+	}
+	// there may be no default input device
+	def, _ := portaudio.DefaultInputDevice()
+	devices = []Device{}
+	for _, info := range infos {
+		if info.MaxInputChannels < 1 {
+			continue
+		}
+		d := Device{
+			Index:       info.Index,
+			Name:        info.Name,
+			Channels:    info.MaxInputChannels,
+			Rates:       []float64{},
+			DefaultRate: info.DefaultSampleRate,
+			Default:     def != nil && def.Index == info.Index,
+		}
+		if info.HostApi != nil {
+			d.HostAPI = info.HostApi.Name
+		}
+		p := portaudio.HighLatencyParameters(info, nil)
+		for _, rate := range probeRates {
+			p.SampleRate = rate
+			if portaudio.IsFormatSupported(p, []int16{}) == nil {
+				d.Rates = append(d.Rates, rate)
+			}
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
+
+// lookupDevice finds the input device by index or name, a name may be any
+// unambiguous part of it. The default input device is returned when device
+// is empty. Portaudio must be initialized.
+func lookupDevice(device string) (*portaudio.DeviceInfo, error) {
+	if device == "" {
+		d, err := portaudio.DefaultInputDevice()
+		if err != nil {
+			return nil, fmt.Errorf("default input device: %w", err)
+		}
+		return d, nil
+	}
+	infos, err := portaudio.Devices()
+	if err != nil {
+		return nil, fmt.Errorf("portaudio devices: %w", err)
+	}
+	inputs := []*portaudio.DeviceInfo{}
+	for _, info := range infos {
+		if info.MaxInputChannels > 0 {
+			inputs = append(inputs, info)
+		}
+	}
+	if i, err := strconv.Atoi(device); err == nil {
+		for _, info := range inputs {
+			if info.Index == i {
+				return info, nil
+			}
+		}
+		return nil, fmt.Errorf("no input device with index %d", i)
+	}
+	var found []*portaudio.DeviceInfo
+	for _, info := range inputs {
+		if info.Name == device {
+			return info, nil
+		}
+		if strings.Contains(strings.ToLower(info.Name), strings.ToLower(device)) {
+			found = append(found, info)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("no input device named %q", device)
+	case 1:
+		return found[0], nil
+	}
+	names := make([]string, len(found))
+	for i, info := range found {
+		names[i] = strconv.Quote(info.Name)
+	}
+	return nil, fmt.Errorf("input device %q is ambiguous: %s", device, strings.Join(names, ", "))
+}
+
+// framesPerBuffer is the number of frames read at once from the input
+// stream.
+const framesPerBuffer = 64
+
+// inputDevice is the device opened by the sampler, it must have enough
+// channels. Portaudio must be initialized.
+func (s sampler) inputDevice() (*portaudio.DeviceInfo, error) {
+	if s.channels < 1 {
+		return nil, fmt.Errorf("%d channels", s.channels)
+	}
+	d, err := lookupDevice(s.device)
+	if err != nil {
+		return nil, err
+	}
+	if d.MaxInputChannels < s.channels {
+		return nil, fmt.Errorf("input device %q has %d channels, %d requested",
+			d.Name, d.MaxInputChannels, s.channels)
+	}
+	return d, nil
+}
+
+// downmix averages the channels of the interleaved frames in into mono.
+func downmix(mono, in []int16, channels int) {
+	for i := range mono {
+		var sum int
+		for _, v := range in[i*channels : (i+1)*channels] {
+			sum += int(v)
+		}
+		mono[i] = int16(sum / channels)
+	}
+}
+
+// deinterleave copies the channel ch of the interleaved frames in.
+func deinterleave(mono, in []int16, channels, ch int) {
+	for i := range mono {
+		mono[i] = in[i*channels+ch]
+	}
+}
@@ -0,0 +1,21 @@
+package sampler
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestChannels(t *testing.T) {
+	// two stereo frames
+	in := []int16{100, 300, -200, -400}
+	mono := make([]int16, 2)
+
+	downmix(mono, in, 2)
+	if want := []int16{200, -300}; !slices.Equal(mono, want) {
+		t.Errorf("downmix: got %v, want %v", mono, want)
+	}
+	deinterleave(mono, in, 2, 1)
+	if want := []int16{300, -400}; !slices.Equal(mono, want) {
+		t.Errorf("deinterleave: got %v, want %v", mono, want)
+	}
+}
@@ -0,0 +1,129 @@
+package sampler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultEncodeWorkers is the number of chunks encoded at once.
+const defaultEncodeWorkers = 2
+
+// encodeJob is a chunk taken from the ring, done is closed once it is
+// encoded or failed.
+type encodeJob struct {
+	c    chunk
+	done chan struct{}
+	// raw is the path of the raw chunk, written once it is on disk.
+	raw     string
+	written bool
+	// flac is the path of the encoded chunk, empty when err is set.
+	flac string
+	err  error
+	took time.Duration
+}
+
+// consume encodes the chunks of r until it is closed and empty, up to
+// s.workers at once. The chunks complete in order: their events and stats
+// follow the capture whichever encoder finishes first. A failed chunk does
+// not stop the next ones.
+func (s sampler) consume(r *ring) {
+	slots := make(chan struct{}, max(s.workers, 1))
+	pending := make(chan *encodeJob, cap(slots))
+	completed := make(chan struct{})
+	go func() {
+		defer close(completed)
+		for j := range pending {
+			<-j.done
+			s.complete(j)
+		}
+	}()
+	for {
+		// a chunk is taken only once an encoder is free, so that the
+		// ring may still apply the policy to the chunks queued
+		slots <- struct{}{}
+		c, ok := r.take()
+		if !ok {
+			break
+		}
+		j := &encodeJob{c: c, done: make(chan struct{})}
+		pending <- j
+		go func() {
+			defer func() { <-slots }()
+			defer close(j.done)
+			s.encode(r, j)
+		}()
+	}
+	close(pending)
+	<-completed
+}
+
+// spill writes the raw chunk, encode converts it later.
+func (s sampler) spill(c chunk) error {
+	pr := s.e.outPath(c.ts, c.channel, s.e.rawType())
+	if err := s.e.writeRaw(pr, c.raw); err != nil {
+		return fmt.Errorf("spill chunk %q: %w", pr, err)
+	}
+	return nil
+}
+
+// encode writes the raw chunk of j, releasing its buffer, and converts it to
+// flac.
+func (s sampler) encode(r *ring, j *encodeJob) {
+	start := time.Now()
+	c := j.c
+	j.raw = s.e.outPath(c.ts, c.channel, s.e.rawType())
+	if !c.spilled {
+		err := s.e.writeRaw(j.raw, c.raw)
+		r.release(c)
+		if err != nil {
+			j.err = fmt.Errorf("write chunk %q: %w", j.raw, err)
+			return
+		}
+	}
+	j.written = true
+	// the raw chunk is kept when encoding fails
+	if err := convert(s.e, c.ts, c.channel, strconv.Itoa(int(s.sample))); err != nil {
+		j.err = err
+		return
+	}
+	j.flac = s.e.outPath(c.ts, c.channel, s.e.chunkType())
+	j.took = time.Since(start)
+	// only the flac chunk is kept, sealed chunks have no mp3 step
+	rm := []string{j.raw}
+	if len(s.e.recipients) == 0 {
+		rm = append(rm, s.e.outPath(c.ts, c.channel, outMp3))
+	}
+	var errs []error
+	for _, p := range rm {
+		if err := os.Remove(p); err != nil {
+			errs = append(errs, fmt.Errorf("remove %q: %w", p, err))
+		}
+	}
+	j.err = errors.Join(errs...)
+}
+
+// complete publishes the events of an encoded job and counts it.
+func (s sampler) complete(j *encodeJob) {
+	if j.written {
+		s.events.publish(s.chunkEvent(EventChunkWritten, j.c, j.raw, nil))
+	}
+	if j.flac == "" {
+		s.stats.encoderFailure()
+		s.events.publish(s.chunkEvent(EventEncoderFailed, j.c, j.raw, j.err))
+		return
+	}
+	s.stats.chunk(j.flac, j.c.ts)
+	s.stats.encoded(j.took)
+	s.events.publish(s.chunkEvent(EventChunkEncoded, j.c, j.flac, j.err))
+}
+
+func (s sampler) chunkEvent(kind EventKind, c chunk, p string, err error) Event {
+	ev := Event{Kind: kind, Chunk: p, Channel: c.channel, Err: err}
+	if c.channel > 0 && c.channel <= len(s.actors) {
+		ev.Actor = s.actors[c.channel-1]
+	}
+	return ev
+}
@@ -0,0 +1,76 @@
+package sampler
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestConsume encodes with a fake ffmpeg slow for the channel 1 and failing
+// for the channel 3.
+func TestConsume(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake ffmpeg is a shell script")
+	}
+	root := t.TempDir()
+	ffmpeg := filepath.Join(root, "ffmpeg")
+	script := `#!/bin/sh
+case "$*" in
+*.ch1.*) sleep 0.2 ;;
+*.ch3.*) exit 1 ;;
+esac
+for out; do :; done
+touch "$out"
+`
+	if err := os.WriteFile(ffmpeg, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	s := New(16000, time.Second,
+		OptionEncoder(EncoderOptionPath(ffmpeg), EncoderOptionRoot(root)),
+		OptionChannels(4),
+		OptionSplitChannels(),
+		OptionEncodeWorkers(4)).(*sampler)
+	events, unsubscribe := s.Subscribe()
+	defer unsubscribe()
+
+	r := newRing(4, 8, PolicyBlock, s.spill, s.stats)
+	ts := time.Now()
+	for ch := 1; ch <= 4; ch++ {
+		r.put(chunk{raw: append(r.get(), 0, 0), ts: ts, channel: ch})
+	}
+	r.close()
+	s.consume(r)
+
+	type completed struct {
+		kind    EventKind
+		channel int
+	}
+	want := []completed{
+		{EventChunkWritten, 1}, {EventChunkEncoded, 1},
+		{EventChunkWritten, 2}, {EventChunkEncoded, 2},
+		{EventChunkWritten, 3}, {EventEncoderFailed, 3},
+		{EventChunkWritten, 4}, {EventChunkEncoded, 4},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d", len(events), len(want))
+	}
+	for i, w := range want {
+		ev := <-events
+		if got := (completed{ev.Kind, ev.Channel}); got != w {
+			t.Errorf("event %d: got %v, want %v", i, got, w)
+		}
+	}
+	if _, err := os.Stat(s.e.outPath(ts, 3, outRaw)); err != nil {
+		t.Errorf("raw chunk of the failed encoding: %s", err)
+	}
+	st := s.Stats()
+	if st.Encode.Count != 3 || st.EncoderFailures != 1 || st.Chunks != 3 {
+		t.Errorf("got %d encoded, %d failures and %d chunks, want 3, 1 and 3",
+			st.Encode.Count, st.EncoderFailures, st.Chunks)
+	}
+	if st.Encode.Max < 200*time.Millisecond {
+		t.Errorf("max encode latency %s, want the slow channel 1", st.Encode.Max)
+	}
+}
@@ -0,0 +1,97 @@
+package sampler
+
+//go:generate stringer -type=EventKind
+
+import (
+	"sync"
+	"time"
+)
+
+// EventKind is what happened to a sampler.
+type EventKind int
+
+const (
+	// EventStarted is published once the input stream runs.
+	EventStarted EventKind = iota
+	// EventChunkWritten is published when a raw chunk is on disk.
+	EventChunkWritten
+	// EventChunkEncoded is published when a chunk is encoded to flac.
+	EventChunkEncoded
+	// EventEncoderFailed is published when a chunk could not be encoded,
+	// its raw chunk is kept.
+	EventEncoderFailed
+	// EventDeviceLost is published when reading the input stream fails.
+	EventDeviceLost
+	// EventStopped is the last event of a Sample call, Err is what Sample
+	// returned.
+	EventStopped
+	// EventClipping is published when a track saturates, Level is its
+	// level.
+	EventClipping
+	EventClippingCleared
+	// EventSilence is published when a track stayed silent longer than
+	// OptionSilenceAfter, e.g. a muted microphone.
+	EventSilence
+	EventSilenceCleared
+)
+
+// Event is published to the subscribers of a sampler.
+type Event struct {
+	Kind EventKind
+	At   time.Time
+	// Chunk is the path of the raw or encoded chunk.
+	Chunk string
+	// Channel is the channel of a split chunk, 0 otherwise.
+	Channel int
+	// Actor speaks into Channel (see OptionChannelActors).
+	Actor string
+	// Level is set for the alarms.
+	Level Level
+	// Err is why a chunk failed or a sampling stopped, it is also set for
+	// an encoded chunk whose intermediate files could not be removed.
+	Err error
+}
+
+// eventsBuffer is the capacity of a subscription, events are dropped for
+// subscribers falling behind so they never block the sampler.
+const eventsBuffer = 64
+
+type events struct {
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+func (e *events) subscribe() (<-chan Event, func()) {
+	c := make(chan Event, eventsBuffer)
+	e.mu.Lock()
+	if e.subs == nil {
+		e.subs = make(map[chan Event]struct{})
+	}
+	e.subs[c] = struct{}{}
+	e.mu.Unlock()
+	var once sync.Once
+	return c, func() {
+		once.Do(func() {
+			e.mu.Lock()
+			delete(e.subs, c)
+			e.mu.Unlock()
+			close(c)
+		})
+	}
+}
+
+func (e *events) publish(ev Event) {
+	ev.At = time.Now()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for c := range e.subs {
+		select {
+		case c <- ev:
+		default:
+		}
+	}
+}
+
+func (s sampler) Subscribe() (<-chan Event, func()) {
+	return s.events.subscribe()
+}
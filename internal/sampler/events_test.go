@@ -0,0 +1,40 @@
+package sampler
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEvents(t *testing.T) {
+	var e events
+	a, unsubscribeA := e.subscribe()
+	b, unsubscribeB := e.subscribe()
+	defer unsubscribeB()
+
+	errDevice := errors.New("device unplugged")
+	e.publish(Event{Kind: EventStarted})
+	e.publish(Event{Kind: EventDeviceLost, Err: errDevice})
+	for _, c := range []<-chan Event{a, b} {
+		if ev := <-c; ev.Kind != EventStarted {
+			t.Errorf("got %v, want %v", ev.Kind, EventStarted)
+		}
+		if ev := <-c; ev.Kind != EventDeviceLost || !errors.Is(ev.Err, errDevice) {
+			t.Errorf("got %v %v, want %v %v", ev.Kind, ev.Err, EventDeviceLost, errDevice)
+		}
+	}
+
+	unsubscribeA()
+	unsubscribeA()
+	if _, ok := <-a; ok {
+		t.Error("unsubscribed channel still open")
+	}
+	e.publish(Event{Kind: EventStopped})
+
+	// a subscriber falling behind never blocks publish
+	for range eventsBuffer + 1 {
+		e.publish(Event{Kind: EventChunkEncoded, Chunk: "chunk.flac"})
+	}
+	if got := len(b); got != eventsBuffer {
+		t.Errorf("buffered %d events, want %d", got, eventsBuffer)
+	}
+}
@@ -0,0 +1,143 @@
+package sampler
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// meterWindow is the span of the rolling RMS and peak.
+	meterWindow = time.Second
+	// clipLevel is the level from which a sample is clipped.
+	clipLevel = 0.99
+	// clipRatio is the ratio of clipped samples in the window raising the
+	// clipping alarm.
+	clipRatio = 0.001
+	// silenceLevel is the rolling RMS under which a track is silent, about
+	// -60 dBFS.
+	silenceLevel = 0.001
+)
+
+// Level meters a track, levels are from 0 to 1.
+type Level struct {
+	// Channel is the split channel metered, 0 for the mono or downmixed
+	// track.
+	Channel int `json:"channel,omitempty"`
+	// RMS and Peak are over the last second.
+	RMS  float64 `json:"rms"`
+	Peak float64 `json:"peak"`
+	// ChunkRMS and ChunkPeak are over the last chunk.
+	ChunkRMS  float64 `json:"chunk_rms"`
+	ChunkPeak float64 `json:"chunk_peak"`
+	// Clipping and Silent are the raised alarms.
+	Clipping bool `json:"clipping"`
+	Silent   bool `json:"silent"`
+	// QuietSince is when the track went under the silence level.
+	QuietSince time.Time `json:"quiet_since,omitzero"`
+}
+
+// DBFS converts a level to decibels relative to full scale, floored at
+// -96 dBFS.
+func DBFS(level float64) float64 {
+	if level <= 0 {
+		return -96
+	}
+	return max(20*math.Log10(level), -96)
+}
+
+type bufferLevel struct {
+	sumsq   float64
+	peak    float64
+	clipped int
+	n       int
+}
+
+func (b *bufferLevel) add(v int16) {
+	f := math.Abs(float64(v) / math.MaxInt16)
+	b.sumsq += f * f
+	b.peak = max(b.peak, f)
+	if f >= clipLevel {
+		b.clipped++
+	}
+	b.n++
+}
+
+func (b bufferLevel) rms() float64 {
+	if b.n == 0 {
+		return 0
+	}
+	return math.Sqrt(b.sumsq / float64(b.n))
+}
+
+// meter keeps the rolling levels of a track from the buffers of a window.
+type meter struct {
+	level  Level
+	window []bufferLevel
+	next   int
+	chunk  bufferLevel
+	// silenceAfter is how long the track stays quiet before the silence
+	// alarm.
+	silenceAfter time.Duration
+}
+
+func newMeter(channel int, rate float64, silenceAfter time.Duration) *meter {
+	buffers := max(int(meterWindow.Seconds()*rate/framesPerBuffer), 1)
+	return &meter{
+		level:        Level{Channel: channel},
+		window:       make([]bufferLevel, buffers),
+		silenceAfter: silenceAfter,
+	}
+}
+
+// add meters frame read at now and returns the alarms raised or cleared.
+func (m *meter) add(frame []int16, now time.Time) []EventKind {
+	var b bufferLevel
+	for _, v := range frame {
+		b.add(v)
+		m.chunk.add(v)
+	}
+	m.window[m.next] = b
+	m.next = (m.next + 1) % len(m.window)
+
+	var sum bufferLevel
+	for _, w := range m.window {
+		sum.sumsq += w.sumsq
+		sum.peak = max(sum.peak, w.peak)
+		sum.clipped += w.clipped
+		sum.n += w.n
+	}
+	m.level.RMS, m.level.Peak = sum.rms(), sum.peak
+
+	var alarms []EventKind
+	clipping := float64(sum.clipped) > clipRatio*float64(sum.n)
+	if clipping != m.level.Clipping {
+		m.level.Clipping = clipping
+		alarms = append(alarms, alarm(EventClipping, EventClippingCleared, clipping))
+	}
+	quiet := m.level.RMS < silenceLevel
+	switch {
+	case !quiet:
+		m.level.QuietSince = time.Time{}
+	case m.level.QuietSince.IsZero():
+		m.level.QuietSince = now
+	}
+	silent := quiet && now.Sub(m.level.QuietSince) >= m.silenceAfter
+	if silent != m.level.Silent {
+		m.level.Silent = silent
+		alarms = append(alarms, alarm(EventSilence, EventSilenceCleared, silent))
+	}
+	return alarms
+}
+
+// endChunk records the levels of the chunk sent and starts the next one.
+func (m *meter) endChunk() {
+	m.level.ChunkRMS, m.level.ChunkPeak = m.chunk.rms(), m.chunk.peak
+	m.chunk = bufferLevel{}
+}
+
+func alarm(raised, cleared EventKind, on bool) EventKind {
+	if on {
+		return raised
+	}
+	return cleared
+}
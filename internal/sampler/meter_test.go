@@ -0,0 +1,50 @@
+package sampler
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMeter(t *testing.T) {
+	const rate = 16000
+	m := newMeter(0, rate, 2*time.Second)
+	quiet := make([]int16, framesPerBuffer)
+	loud := make([]int16, framesPerBuffer)
+	for i := range loud {
+		loud[i] = math.MaxInt16
+	}
+	buffers := func(d time.Duration) int {
+		return int(d.Seconds() * rate / framesPerBuffer)
+	}
+
+	now := time.Now()
+	var got []EventKind
+	feed := func(frame []int16, d time.Duration) {
+		for range buffers(d) {
+			now = now.Add(framesPerBuffer * time.Second / rate)
+			got = append(got, m.add(frame, now)...)
+		}
+	}
+
+	feed(quiet, time.Second)
+	if len(got) != 0 || m.level.Silent {
+		t.Fatalf("silent after 1s: %v", got)
+	}
+	feed(quiet, 2*time.Second)
+	feed(loud, 100*time.Millisecond)
+	feed(quiet, 2*time.Second)
+	want := []EventKind{EventSilence, EventClipping, EventSilenceCleared, EventClippingCleared}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	m.endChunk()
+	if m.level.ChunkPeak != 1 || m.level.ChunkRMS <= 0 {
+		t.Errorf("chunk level %+v", m.level)
+	}
+	if db := DBFS(silenceLevel); math.Round(db) != -60 {
+		t.Errorf("silence level is %f dBFS", db)
+	}
+}
@@ -0,0 +1,128 @@
+package sampler
+
+import (
+	"math"
+)
+
+const (
+	// resampleZeros is the number of zero crossings of the sinc kernel on
+	// each side, it trades the filter steepness for cpu.
+	resampleZeros = 16
+	// resamplePhases is the number of fractional delays of the kernel
+	// table, the delay of an output sample is rounded to the nearest one.
+	resamplePhases = 256
+	// resampleCutoff is the low-pass cutoff relative to the nyquist
+	// frequency of the lower rate, the band above is the transition band.
+	resampleCutoff = 0.9
+)
+
+// resampler converts a stream of mono samples from a rate to another with
+// a windowed sinc low-pass filter, so that the frequencies above the output
+// nyquist frequency do not alias.
+type resampler struct {
+	// step is the input samples between two output samples.
+	step float64
+	half int
+	// kernel holds the taps of each phase, tap j weights the input sample
+	// j-half+1 samples away from the output sample.
+	kernel [][]float64
+	// in holds the input samples not consumed yet, pos is the position of
+	// the next output sample in it.
+	in  []float64
+	pos float64
+}
+
+func newResampler(inRate, outRate float64) *resampler {
+	r := &resampler{step: inRate / outRate}
+	if inRate == outRate {
+		return r
+	}
+	// cutoff in cycles per input sample, relative to the input nyquist
+	fc := resampleCutoff * min(1, outRate/inRate)
+	r.half = int(math.Ceil(resampleZeros / fc))
+	r.kernel = make([][]float64, resamplePhases+1)
+	for p := range r.kernel {
+		frac := float64(p) / resamplePhases
+		taps := make([]float64, 2*r.half)
+		var sum float64
+		for j := range taps {
+			x := float64(j-r.half+1) - frac
+			taps[j] = fc * sinc(fc*x) * blackman(x/float64(r.half))
+			sum += taps[j]
+		}
+		// unity gain at dc
+		for j := range taps {
+			taps[j] /= sum
+		}
+		r.kernel[p] = taps
+	}
+	r.reset()
+	return r
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// blackman is the blackman window over [-1, 1].
+func blackman(x float64) float64 {
+	if x <= -1 || x >= 1 {
+		return 0
+	}
+	t := math.Pi * (x + 1)
+	return 0.42 - 0.5*math.Cos(t) + 0.08*math.Cos(2*t)
+}
+
+// process appends to dst the output samples of src.
+func (r *resampler) process(dst, src []int16) []int16 {
+	if r.kernel == nil {
+		return append(dst, src...)
+	}
+	for _, v := range src {
+		r.in = append(r.in, float64(v))
+	}
+	return r.drain(dst)
+}
+
+// flush appends to dst the output samples still depending on input samples
+// that will never come.
+func (r *resampler) flush(dst []int16) []int16 {
+	if r.kernel == nil {
+		return dst
+	}
+	r.in = append(r.in, make([]float64, r.half)...)
+	dst = r.drain(dst)
+	r.reset()
+	return dst
+}
+
+// reset forgets the input samples, as if none came before.
+func (r *resampler) reset() {
+	r.in = append(r.in[:0], make([]float64, r.half-1)...)
+	r.pos = float64(r.half - 1)
+}
+
+func (r *resampler) drain(dst []int16) []int16 {
+	for {
+		i := int(r.pos)
+		if i+r.half >= len(r.in) {
+			break
+		}
+		taps := r.kernel[int(math.Round((r.pos-float64(i))*resamplePhases))]
+		var y float64
+		for j, w := range taps {
+			y += w * r.in[i-r.half+1+j]
+		}
+		dst = append(dst, int16(max(min(math.Round(y), math.MaxInt16), math.MinInt16)))
+		r.pos += r.step
+	}
+	// drop the samples before the kernel of the next output sample
+	if drop := int(r.pos) - r.half + 1; drop > 0 {
+		r.in = r.in[:copy(r.in, r.in[drop:])]
+		r.pos -= float64(drop)
+	}
+	return dst
+}
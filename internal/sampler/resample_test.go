@@ -0,0 +1,111 @@
+package sampler
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+// tone is d seconds of a sine of frequency f and amplitude a sampled at
+// rate.
+func tone(f, a, rate float64, d float64) []int16 {
+	s := make([]int16, int(d*rate))
+	for i := range s {
+		s[i] = int16(a * math.MaxInt16 * math.Sin(2*math.Pi*f*float64(i)/rate))
+	}
+	return s
+}
+
+// spectrum is the hann windowed magnitude of the dft bins of s, bin k is
+// k*rate/len(s) Hz.
+func spectrum(s []int16) []float64 {
+	n := len(s)
+	mag := make([]float64, n/2)
+	for k := range mag {
+		var sum complex128
+		for i, v := range s {
+			w := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n))
+			sum += complex(w*float64(v), 0) * cmplx.Exp(complex(0, -2*math.Pi*float64(k*i)/float64(n)))
+		}
+		mag[k] = cmplx.Abs(sum)
+	}
+	return mag
+}
+
+// resample runs src in buffers of framesPerBuffer through r, as the
+// sampler does.
+func resample(r *resampler, src []int16) []int16 {
+	var out []int16
+	for len(src) > 0 {
+		n := min(framesPerBuffer, len(src))
+		out = r.process(out, src[:n])
+		src = src[n:]
+	}
+	return r.flush(out)
+}
+
+func TestResampleSpectrum(t *testing.T) {
+	const out = 16000
+	for _, tc := range []struct {
+		name string
+		rate float64
+		// f is the tone frequency, alias the one it folds to at 16 kHz
+		f     float64
+		alias float64
+		// gain is the expected level of the tone, in dB
+		gain float64
+	}{
+		{"48k passband", 48000, 1000, 0, 0},
+		{"44.1k passband", 44100, 3000, 0, 0},
+		{"48k above nyquist", 48000, 11000, 5000, -60},
+		{"44.1k above nyquist", 44100, 9500, 6500, -60},
+		{"8k upsampling", 8000, 1000, 0, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			in := tone(tc.f, 0.5, tc.rate, 0.5)
+			got := resample(newResampler(tc.rate, out), in)
+			if want := len(in) * out / int(tc.rate); abs(len(got)-want) > 1 {
+				t.Fatalf("got %d samples, want %d", len(got), want)
+			}
+			// a 0.1s window in the steady state, 10 Hz bins
+			window := got[out/10 : 2*out/10]
+			mag := spectrum(window)
+			ref := spectrum(tone(1000, 0.5, out, 0.1))[100]
+			bin := func(f float64) int { return int(f / 10) }
+
+			if tc.alias == 0 {
+				db := 20 * math.Log10(mag[bin(tc.f)]/ref)
+				if math.Abs(db-tc.gain) > 0.5 {
+					t.Errorf("tone at %.1f dB, want %.1f dB", db, tc.gain)
+				}
+				// nothing else than the tone and its window leakage
+				for k, m := range mag {
+					if abs(k-bin(tc.f)) > 2 && 20*math.Log10(m/ref) > -50 {
+						t.Errorf("%d Hz at %.1f dB", k*10, 20*math.Log10(m/ref))
+					}
+				}
+				return
+			}
+			if db := 20 * math.Log10(mag[bin(tc.alias)]/ref); db > tc.gain {
+				t.Errorf("alias at %.0f Hz is %.1f dB, want under %.1f dB", tc.alias, db, tc.gain)
+			}
+		})
+	}
+}
+
+func TestResampleSameRate(t *testing.T) {
+	in := tone(1000, 0.5, 16000, 0.01)
+	got := resample(newResampler(16000, 16000), in)
+	for i := range in {
+		if got[i] != in[i] {
+			t.Fatalf("sample %d changed", i)
+		}
+	}
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
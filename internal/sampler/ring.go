@@ -0,0 +1,183 @@
+package sampler
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Policy is what the capture does when every chunk buffer is queued for
+// encoding, e.g. when ffmpeg is slower than the input.
+type Policy int
+
+const (
+	// PolicyBlock waits for the encoder, the input device overflows
+	// meanwhile.
+	PolicyBlock Policy = iota
+	// PolicyDropOldest drops the oldest chunk queued for encoding.
+	PolicyDropOldest
+	// PolicySpill writes the oldest chunk queued to disk and reuses its
+	// buffer, it is encoded from the disk later.
+	PolicySpill
+)
+
+var policies = []string{"block", "drop-oldest", "spill"}
+
+func (p Policy) String() string {
+	if p < 0 || int(p) >= len(policies) {
+		return fmt.Sprintf("Policy(%d)", p)
+	}
+	return policies[p]
+}
+
+// ParsePolicy parses the name of a policy: block, drop-oldest or spill.
+func ParsePolicy(s string) (Policy, error) {
+	i := slices.Index(policies, s)
+	if i < 0 {
+		return 0, fmt.Errorf("unknown policy %q, want one of %s", s, strings.Join(policies, ", "))
+	}
+	return Policy(i), nil
+}
+
+// queueDepth is the number of chunks of each track that may wait for the
+// encoder before the policy applies.
+const queueDepth = 4
+
+// ring cycles a fixed set of chunk buffers between the capture filling
+// them and the encoder, chunks are queued in order.
+type ring struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	free [][]byte
+	// queued is a fifo of n chunks from head, it only grows with the
+	// chunks spilled to disk.
+	queued []chunk
+	head   int
+	n      int
+	closed bool
+
+	policy Policy
+	// spill writes the raw chunk to disk.
+	spill func(chunk) error
+	stats *stats
+}
+
+// newRing allocates the buffers of (queueDepth+1)*tracks chunks of size
+// bytes.
+func newRing(tracks, size int, policy Policy, spill func(chunk) error, st *stats) *ring {
+	r := &ring{
+		free:   make([][]byte, (queueDepth+1)*tracks),
+		queued: make([]chunk, (queueDepth+1)*tracks),
+		policy: policy,
+		spill:  spill,
+		stats:  st,
+	}
+	r.cond = sync.NewCond(&r.mu)
+	for i := range r.free {
+		r.free[i] = make([]byte, 0, size)
+	}
+	return r
+}
+
+// get returns an empty buffer, applying the policy when none is free.
+func (r *ring) get() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for len(r.free) == 0 {
+		if r.policy == PolicyBlock || !r.evict() {
+			r.cond.Wait()
+		}
+	}
+	b := r.free[len(r.free)-1]
+	r.free = r.free[:len(r.free)-1]
+	return b[:0]
+}
+
+// evict frees the buffer of the oldest chunk queued still holding one,
+// it is false when the encoder holds every buffer.
+func (r *ring) evict() bool {
+	for i := range r.n {
+		c := &r.queued[(r.head+i)%len(r.queued)]
+		if c.spilled {
+			continue
+		}
+		frames := len(c.raw) / 2
+		if r.policy == PolicySpill {
+			if err := r.spill(*c); err == nil {
+				r.stats.spilled()
+				r.free = append(r.free, c.raw)
+				c.raw, c.spilled = nil, true
+				return true
+			}
+			// the chunk is dropped when it can't be spilled
+		}
+		r.stats.dropped(frames)
+		r.free = append(r.free, c.raw)
+		r.remove(i)
+		return true
+	}
+	return false
+}
+
+// remove removes the i-th queued chunk.
+func (r *ring) remove(i int) {
+	for ; i < r.n-1; i++ {
+		r.queued[(r.head+i)%len(r.queued)] = r.queued[(r.head+i+1)%len(r.queued)]
+	}
+	r.n--
+	r.queued[(r.head+r.n)%len(r.queued)] = chunk{}
+}
+
+// put queues a filled chunk.
+func (r *ring) put(c chunk) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.n == len(r.queued) {
+		grown := make([]chunk, 2*len(r.queued))
+		for i := range r.n {
+			grown[i] = r.queued[(r.head+i)%len(r.queued)]
+		}
+		r.queued, r.head = grown, 0
+	}
+	r.queued[(r.head+r.n)%len(r.queued)] = c
+	r.n++
+	r.cond.Broadcast()
+}
+
+// take returns the oldest queued chunk, it is false once the ring is
+// closed and empty.
+func (r *ring) take() (chunk, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for r.n == 0 && !r.closed {
+		r.cond.Wait()
+	}
+	if r.n == 0 {
+		return chunk{}, false
+	}
+	c := r.queued[r.head]
+	r.queued[r.head] = chunk{}
+	r.head = (r.head + 1) % len(r.queued)
+	r.n--
+	return c, true
+}
+
+// release gives back the buffer of a chunk taken.
+func (r *ring) release(c chunk) {
+	if c.raw == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.free = append(r.free, c.raw)
+	r.cond.Broadcast()
+}
+
+// close lets take return false once the queued chunks are taken.
+func (r *ring) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	r.cond.Broadcast()
+}
@@ -0,0 +1,77 @@
+package sampler
+
+import (
+	"testing"
+	"time"
+)
+
+// fill queues queueDepth+1 chunks with no encoder, the ring runs out of
+// buffers on the last get.
+func fill(r *ring) chan []byte {
+	got := make(chan []byte, 1)
+	cur := r.get()
+	for i := range queueDepth {
+		r.put(chunk{raw: append(cur, byte(i)), ts: time.Unix(int64(i), 0)})
+		cur = r.get()
+	}
+	r.put(chunk{raw: append(cur, queueDepth), ts: time.Unix(queueDepth, 0)})
+	go func() { got <- r.get() }()
+	return got
+}
+
+// taken lists the chunks of the ring by timestamp.
+func taken(r *ring) []int64 {
+	r.close()
+	var ts []int64
+	for {
+		c, ok := r.take()
+		if !ok {
+			return ts
+		}
+		ts = append(ts, c.ts.Unix())
+	}
+}
+
+func TestRingPolicy(t *testing.T) {
+	t.Run("drop oldest", func(t *testing.T) {
+		st := &stats{}
+		r := newRing(1, 8, PolicyDropOldest, nil, st)
+		<-fill(r)
+		if got := taken(r); len(got) != queueDepth || got[0] != 1 {
+			t.Errorf("got chunks %v", got)
+		}
+		if s := st.get(); s.DroppedChunks != 1 || s.DroppedFrames != 0 {
+			t.Errorf("dropped %d chunks, %d frames", s.DroppedChunks, s.DroppedFrames)
+		}
+	})
+	t.Run("spill", func(t *testing.T) {
+		st := &stats{}
+		var spilled []int64
+		r := newRing(1, 8, PolicySpill, func(c chunk) error {
+			spilled = append(spilled, c.ts.Unix())
+			return nil
+		}, st)
+		<-fill(r)
+		if got := taken(r); len(got) != queueDepth+1 || got[0] != 0 {
+			t.Errorf("got chunks %v", got)
+		}
+		if len(spilled) != 1 || spilled[0] != 0 || st.get().SpilledChunks != 1 {
+			t.Errorf("spilled %v", spilled)
+		}
+	})
+	t.Run("block", func(t *testing.T) {
+		r := newRing(1, 8, PolicyBlock, nil, &stats{})
+		got := fill(r)
+		select {
+		case <-got:
+			t.Fatal("get did not block")
+		case <-time.After(50 * time.Millisecond):
+		}
+		c, _ := r.take()
+		r.release(c)
+		<-got
+		if got := taken(r); len(got) != queueDepth {
+			t.Errorf("got chunks %v", got)
+		}
+	})
+}
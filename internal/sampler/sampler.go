@@ -1,29 +1,120 @@
 package sampler
 
 import (
-	"bytes"
 	"context"
-	"encoding/binary"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/exec"
 	"path"
-	"strconv"
 	"time"
 
+	"filippo.io/age"
 	"github.com/gordonklaus/portaudio"
 )
 
-func New(sampleRate float64, splitPeriod time.Duration, encoderOpts ...EncoderOption) Sampler {
-	s := &sampler{
-		e:         NewEncoder(encoderOpts...),
+func New(sampleRate float64, splitPeriod time.Duration, opts ...Option) Sampler {
+	s := sampler{
+		e:         NewEncoder(),
 		sample:    sampleRate,
 		splitFreq: splitPeriod,
-		chunk:     make(chan chunk, 5),
-		err:       make(chan error),
+		channels:  1,
+		silence:   defaultSilenceAfter,
+		policy:    PolicySpill,
+		workers:   defaultEncodeWorkers,
+		stats:     &stats{},
+		events:    &events{},
+	}
+	for _, opt := range opts {
+		s = opt(s)
+	}
+	return &s
+}
+
+type Option func(sampler) sampler
+
+func OptionEncoder(opts ...EncoderOption) Option {
+	return func(s sampler) sampler {
+		s.e = NewEncoder(opts...)
+		return s
+	}
+}
+
+// OptionDevice opens the input device of this index or name (see Devices)
+// instead of the default one.
+func OptionDevice(device string) Option {
+	return func(s sampler) sampler {
+		s.device = device
+		return s
+	}
+}
+
+// OptionCaptureRate captures at rate instead of the device default rate,
+// the input is resampled to the sample rate of the chunks either way.
+func OptionCaptureRate(rate float64) Option {
+	return func(s sampler) sampler {
+		s.capture = rate
+		return s
+	}
+}
+
+// OptionBackpressure sets what the capture does when the encoder falls
+// behind, PolicySpill by default.
+func OptionBackpressure(p Policy) Option {
+	return func(s sampler) sampler {
+		s.policy = p
+		return s
+	}
+}
+
+// OptionEncodeWorkers encodes up to n chunks at once, defaultEncodeWorkers
+// by default.
+func OptionEncodeWorkers(n int) Option {
+	return func(s sampler) sampler {
+		s.workers = n
+		return s
+	}
+}
+
+// OptionChannels captures n channels, they are downmixed to a mono chunk
+// unless OptionSplitChannels.
+func OptionChannels(n int) Option {
+	return func(s sampler) sampler {
+		s.channels = n
+		return s
+	}
+}
+
+// OptionChannelActors names the actor speaking into each split channel,
+// the first one into channel 1. The names tag the chunk events.
+func OptionChannelActors(actors []string) Option {
+	return func(s sampler) sampler {
+		s.actors = actors
+		return s
+	}
+}
+
+// defaultSilenceAfter is how long a track stays quiet before the silence
+// alarm.
+const defaultSilenceAfter = 30 * time.Second
+
+// OptionSilenceAfter raises the silence alarm once a track stayed quiet for
+// d.
+func OptionSilenceAfter(d time.Duration) Option {
+	return func(s sampler) sampler {
+		s.silence = d
+		return s
+	}
+}
+
+// OptionSplitChannels writes one chunk per channel, the channel index
+// starting at 1 is part of the chunk name, e.g. 20060102150405,000.ch1.flac.
+func OptionSplitChannels() Option {
+	return func(s sampler) sampler {
+		s.split = true
+		return s
 	}
-	return s
 }
 
 func DefaultSys32(root string) Sampler {
@@ -31,8 +122,12 @@ func DefaultSys32(root string) Sampler {
 		e:         NewEncoder(NewSys32Opt(), EncoderOptionRoot(root)),
 		sample:    16000,
 		splitFreq: time.Second * 10,
-		chunk:     make(chan chunk, 5),
-		err:       make(chan error),
+		channels:  1,
+		silence:   defaultSilenceAfter,
+		policy:    PolicySpill,
+		workers:   defaultEncodeWorkers,
+		stats:     &stats{},
+		events:    &events{},
 	}
 }
 
@@ -41,126 +136,131 @@ func NewSys32Opt() EncoderOption {
 }
 
 type Sampler interface {
-	Sample(ctx context.Context)
+	// Sample records until ctx is done or capture fails, it returns once
+	// the partial chunk and the chunks still queued are encoded.
+	Sample(ctx context.Context) error
+	// Subscribe streams the sampler events until unsubscribe is called.
+	Subscribe() (events <-chan Event, unsubscribe func())
+	Stats() Stats
+	// CheckDevice and CheckEncoder report whether sampling can start.
+	CheckDevice() error
+	CheckEncoder() error
 }
 
 type sampler struct {
 	e         Encoder
 	sample    float64
 	splitFreq time.Duration
-	chunk     chan chunk
-	err       chan error
+	device    string
+	capture   float64
+	channels  int
+	split     bool
+	actors    []string
+	silence   time.Duration
+	policy    Policy
+	workers   int
+	stats     *stats
+	events    *events
 }
 
-func (s sampler) Sample(ctx context.Context) {
-	go func() {
-		for err := range s.err {
-			slog.Error("sample failed", "err", err)
-		}
+func (s sampler) Sample(ctx context.Context) (err error) {
+	defer func() {
+		s.events.publish(Event{Kind: EventStopped, Err: err})
 	}()
-	go func() {
-		if err := s.consume(ctx); err != nil {
-			s.err <- fmt.Errorf("consume: %w", err)
+	if len(s.e.recipients) > 0 {
+		if s.e.rawKey, err = age.GenerateX25519Identity(); err != nil {
+			return fmt.Errorf("raw chunks key: %w", err)
 		}
-	}()
-	if err := s.stream(ctx); err != nil {
-		s.err <- fmt.Errorf("stream: %w", err)
 	}
-}
-
-func (s sampler) consume(ctx context.Context) (err error) {
-	defer func() {
-		if err != nil {
-			slog.Error("consume failed", "err", err)
-		}
+	r := newRing(s.tracks(), s.chunkSize(), s.policy, s.spill, s.stats)
+	consumed := make(chan struct{})
+	go func() {
+		defer close(consumed)
+		s.consume(r)
 	}()
-	for {
-		select {
-		case <-ctx.Done():
-			slog.Info("sampler gracefully stopping: context done")
-			return
-		case c := <-s.chunk:
-			pr := s.e.outPath(c.ts, outRaw)
-			slog.Info("writitng new chunk", "to", pr)
-			f, err := os.Create(pr)
-			if err != nil {
-				return fmt.Errorf("os create %q: %w", pr, err)
-			}
-			defer func() {
-				err = f.Close()
-			}()
-			if _, err = f.Write(c.raw); err != nil {
-				return fmt.Errorf("write chunk %q: %w", pr, err)
-			}
-			if err = f.Close(); err != nil {
-				return fmt.Errorf("close chunk %q: %w", pr, err)
-			}
-			err = convert(s.e, c.ts, strconv.Itoa(int(s.sample)))
-			if err != nil {
-				return fmt.Errorf("convert: %w", err)
-			}
-			if err := os.Remove(pr); err != nil {
-				return fmt.Errorf("remove %q: %w", pr, err)
-			}
-		default:
-			time.Sleep(time.Second)
-		}
+	err = s.stream(ctx, r)
+	r.close()
+	<-consumed
+	if err != nil {
+		return fmt.Errorf("stream: %w", err)
 	}
+	return nil
 }
 
-func (s sampler) stream(ctx context.Context) (err error) {
-	defer func() {
-		if err != nil {
-			slog.Error("sample failed", "err", err)
-		}
-	}()
-
+func (s sampler) stream(ctx context.Context, r *ring) (err error) {
 	if err := portaudio.Initialize(); err != nil {
 		return fmt.Errorf("portaudio Initialize: %w", err)
 	}
 	defer func() {
-		err = portaudio.Terminate()
+		if errTerminate := portaudio.Terminate(); err == nil && errTerminate != nil {
+			err = fmt.Errorf("portaudio terminate: %w", errTerminate)
+		}
 	}()
 
-	in := make([]int16, 64)
-	stream, err := portaudio.OpenDefaultStream(1, 0, float64(s.sample), len(in), in)
+	dev, err := s.inputDevice()
+	if err != nil {
+		return err
+	}
+	rate := s.capture
+	if rate == 0 {
+		rate = dev.DefaultSampleRate
+	}
+	p := portaudio.HighLatencyParameters(dev, nil)
+	p.Input.Channels = s.channels
+	p.SampleRate = rate
+	p.FramesPerBuffer = framesPerBuffer
+	// in interleaves the frames of each channel
+	in := make([]int16, framesPerBuffer*s.channels)
+	stream, err := portaudio.OpenStream(p, in)
 	if err != nil {
-		return fmt.Errorf("open stream: %w", err)
+		return fmt.Errorf("open stream on %q: %w", dev.Name, err)
 	}
 	defer func() {
-		err = stream.Close()
+		if errClose := stream.Close(); err == nil && errClose != nil {
+			err = fmt.Errorf("stream close: %w", errClose)
+		}
 	}()
 
 	if err := stream.Start(); err != nil {
 		return fmt.Errorf("stream start: %w", err)
 	}
+	s.events.publish(Event{Kind: EventStarted})
 
 	t := time.NewTicker(time.Duration(s.splitFreq))
+	defer t.Stop()
 
-	var b bytes.Buffer
-
+	c := s.newCapture(rate, r)
 loop:
 	for {
-		if err := stream.Read(); err != nil {
-			return fmt.Errorf("stream read: %w", err)
-		}
-		if err := binary.Write(&b, binary.LittleEndian, in); err != nil {
-			return fmt.Errorf("binary write: %w", err)
+		errRead := stream.Read()
+		switch {
+		case errors.Is(errRead, portaudio.InputOverflowed):
+			// frames were lost before this buffer while the capture was
+			// late, the buffer itself is read
+			s.stats.overflow()
+		case errRead != nil:
+			s.events.publish(Event{Kind: EventDeviceLost, Err: errRead})
+			err = fmt.Errorf("stream read: %w", errRead)
+			break loop
 		}
+		c.write(in, time.Now())
 		select {
 		case <-ctx.Done():
 			break loop
-		case <-t.C:
-			ts := time.Now()
-			s.chunk <- chunk{
-				raw: b.Bytes(),
-				ts:  ts,
-			}
-			b.Reset()
+		case ts := <-t.C:
+			c.cut(ts)
 		default:
 		}
 	}
 
+	// flush the partial chunk
+	c.flush(time.Now())
+
+	s.stats.level(0)
+	s.stats.levels(nil)
+	if err != nil {
+		return err
+	}
 	if err := stream.Stop(); err != nil {
 		return fmt.Errorf("stream stop: %w", err)
 	}
@@ -173,6 +273,10 @@ const (
 	outFlac outType = iota
 	outMp3
 	outRaw
+	// outSealed is a flac chunk encrypted with age.
+	outSealed
+	// outRawSealed is a raw chunk encrypted with age.
+	outRawSealed
 )
 
 func (t outType) String() string {
@@ -182,21 +286,42 @@ func (t outType) String() string {
 	if t == outFlac {
 		return "flac"
 	}
+	if t == outSealed {
+		return "flac.age"
+	}
+	if t == outRawSealed {
+		return "raw.age"
+	}
 	return "raw"
 }
 
-func (e Encoder) outPath(ts time.Time, t outType) string {
-	filename := fmt.Sprintf("%s.%s", ts.Format("20060102150405"), t)
+// chunkLayout names the chunks after their cut to the millisecond, a
+// layout of seconds parses the names too.
+const chunkLayout = "20060102150405,000"
+
+// outPath is the path of the chunk ended at ts, channel is 0 unless the
+// channels are split.
+func (e Encoder) outPath(ts time.Time, channel int, t outType) string {
+	filename := fmt.Sprintf("%s.%s", ts.Format(chunkLayout), t)
+	if channel > 0 {
+		filename = fmt.Sprintf("%s.ch%d.%s", ts.Format(chunkLayout), channel, t)
+	}
 	return path.Join(e.root, filename)
 }
 
 type chunk struct {
-	raw []byte
-	ts  time.Time
+	raw     []byte
+	ts      time.Time
+	channel int
+	// spilled chunks were written to disk, raw is nil.
+	spilled bool
 }
 
-func convert(e Encoder, ts time.Time, freq string) (err error) {
-	raw, mp3 := e.outPath(ts, outRaw), e.outPath(ts, outMp3)
+func convert(e Encoder, ts time.Time, channel int, freq string) (err error) {
+	if len(e.recipients) > 0 {
+		return convertSealed(e, ts, channel, freq)
+	}
+	raw, mp3 := e.outPath(ts, channel, outRaw), e.outPath(ts, channel, outMp3)
 	args := []string{
 		"-f", "s16le",
 		"-ar", freq,
@@ -206,7 +331,7 @@ func convert(e Encoder, ts time.Time, freq string) (err error) {
 	if err = e.encode(mp3, args...); err != nil {
 		return fmt.Errorf("ffmpeg %q->%q: %w", raw, mp3, err)
 	}
-	flac := e.outPath(ts, outFlac)
+	flac := e.outPath(ts, channel, outFlac)
 	args = []string{
 		"-i", mp3,
 		"-ar", freq,
@@ -224,6 +349,10 @@ func convert(e Encoder, ts time.Time, freq string) (err error) {
 type Encoder struct {
 	ffmpegPath string
 	root       string
+	recipients []age.Recipient
+	// rawKey seals the raw chunks of the run with the recipients, the
+	// encoder reads them with it.
+	rawKey *age.X25519Identity
 }
 
 type EncoderOption func(Encoder) Encoder
@@ -259,7 +388,7 @@ func (e Encoder) encode(rm string, args ...string) (err error) {
 			return fmt.Errorf("remove %q: %w", rm, err)
 		}
 	}
-	fmt.Println(append([]string{"ffmpeg"}, args...))
+	slog.Debug("exec ffmpeg", "args", args)
 	{
 		cmd := exec.Command(e.ffmpegPath, args...)
 		//cmd.Stdout = os.Stdout
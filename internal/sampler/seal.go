@@ -0,0 +1,159 @@
+package sampler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+)
+
+// EncoderOptionRecipients encrypts the chunks to recipients with age, they
+// are written as .flac.age files. The raw chunks, spilled or kept when
+// encoding fails, are written as .raw.age files sealed to recipients and to
+// a key of the run the encoder reads them with, and ffmpeg streams them to
+// flac without the mp3 step: a chunk is in clear only in memory and in the
+// ffmpeg pipes.
+func EncoderOptionRecipients(recipients ...age.Recipient) EncoderOption {
+	return func(e Encoder) Encoder {
+		e.recipients = recipients
+		return e
+	}
+}
+
+// chunkType is the type of the encoded chunks.
+func (e Encoder) chunkType() outType {
+	if len(e.recipients) > 0 {
+		return outSealed
+	}
+	return outFlac
+}
+
+// rawType is the type of the raw chunks on disk.
+func (e Encoder) rawType() outType {
+	if len(e.recipients) > 0 {
+		return outRawSealed
+	}
+	return outRaw
+}
+
+// writeRaw writes the raw chunk to p, sealed to the recipients and the key
+// of the run when there are recipients.
+func (e Encoder) writeRaw(p string, raw []byte) (err error) {
+	if len(e.recipients) == 0 {
+		return os.WriteFile(p, raw, 0600)
+	}
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(p)
+		}
+	}()
+	w, err := age.Encrypt(f, append([]age.Recipient{e.rawKey.Recipient()}, e.recipients...)...)
+	if err != nil {
+		return fmt.Errorf("age encrypt: %w", err)
+	}
+	if _, err = w.Write(raw); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return fmt.Errorf("age close: %w", err)
+	}
+	return f.Close()
+}
+
+// convertSealed streams the sealed raw chunk to a sealed flac chunk, the
+// chunk is decrypted with the key of the run as ffmpeg reads it.
+func convertSealed(e Encoder, ts time.Time, channel int, freq string) error {
+	raw, flac := e.outPath(ts, channel, outRawSealed), e.outPath(ts, channel, outSealed)
+	r, err := OpenChunk(raw, e.rawKey)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if err := e.encodeSealed(flac, r,
+		"-f", "s16le",
+		"-ar", freq,
+		"-ac", "1",
+		"-i", "pipe:0",
+		"-map", "0:a",
+		"-c:a", "flac",
+		"-f", "flac", "pipe:1"); err != nil {
+		return fmt.Errorf("ffmpeg %q->%q: %w", raw, flac, err)
+	}
+	return nil
+}
+
+// encodeSealed runs ffmpeg reading stdin, when not nil, and writing to its
+// stdout and encrypts the output to out. The output is renamed to out once
+// complete, so that out is never read partially written.
+func (e Encoder) encodeSealed(out string, stdin io.Reader, args ...string) (err error) {
+	tmp := out + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open %q: %w", tmp, err)
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(tmp)
+		}
+	}()
+	w, err := age.Encrypt(f, e.recipients...)
+	if err != nil {
+		return fmt.Errorf("age encrypt: %w", err)
+	}
+	slog.Debug("exec ffmpeg", "args", args)
+	cmd := exec.Command(e.ffmpegPath, args...)
+	cmd.Stdin, cmd.Stdout = stdin, w
+	if err = cmd.Run(); err != nil {
+		return fmt.Errorf("exec ffmpeg: %w", err)
+	}
+	if err = w.Close(); err != nil {
+		return fmt.Errorf("age close: %w", err)
+	}
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("close %q: %w", tmp, err)
+	}
+	if err = os.Rename(tmp, out); err != nil {
+		return fmt.Errorf("rename %q: %w", tmp, err)
+	}
+	return nil
+}
+
+// OpenChunk opens the chunk name, sealed chunks are decrypted with one of
+// identities as they are read.
+func OpenChunk(name string, identities ...age.Identity) (io.ReadCloser, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("open %q: %w", name, err)
+	}
+	if !strings.HasSuffix(name, ".age") {
+		return f, nil
+	}
+	if len(identities) == 0 {
+		f.Close()
+		return nil, fmt.Errorf("sealed chunk %q: %w", name, ErrNoIdentity)
+	}
+	r, err := age.Decrypt(f, identities...)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("age decrypt %q: %w", name, err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{r, f}, nil
+}
+
+// ErrNoIdentity is returned opening a sealed chunk without identities.
+var ErrNoIdentity = errors.New("no age identity")
@@ -0,0 +1,136 @@
+package sampler
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+)
+
+func TestSeal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake ffmpeg is a shell script")
+	}
+	root := t.TempDir()
+	ffmpeg := filepath.Join(root, "ffmpeg")
+	if err := os.WriteFile(ffmpeg, []byte("#!/bin/sh\necho fLaC\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEncoder(EncoderOptionPath(ffmpeg), EncoderOptionRoot(root), EncoderOptionRecipients(id.Recipient()))
+	out := filepath.Join(root, "20060102150405.flac.age")
+	if err := e.encodeSealed(out, nil, "-f", "flac", "pipe:1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(out + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("partial chunk left: %v", err)
+	}
+
+	if _, err := OpenChunk(out); !errors.Is(err, ErrNoIdentity) {
+		t.Errorf("got %v, want %v", err, ErrNoIdentity)
+	}
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenChunk(out, other); err == nil {
+		t.Error("decrypted with another identity")
+	}
+	r, err := OpenChunk(out, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "fLaC\n" {
+		t.Errorf("decrypted %q, want the ffmpeg output", b)
+	}
+}
+
+// TestConsumeSealed encodes with a fake ffmpeg copying its stdin, failing
+// for the chunk starting with 3: no chunk is left in clear, the failed raw
+// chunk is sealed to the recipients.
+func TestConsumeSealed(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake ffmpeg is a shell script")
+	}
+	bin, root := t.TempDir(), t.TempDir()
+	ffmpeg := filepath.Join(bin, "ffmpeg")
+	script := `#!/bin/sh
+in="$0.$$"
+cat >"$in"
+first=$(head -c 1 "$in" | od -An -tu1 | tr -d ' ')
+[ "$first" = 3 ] && exit 1
+cat "$in"
+`
+	if err := os.WriteFile(ffmpeg, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(16000, time.Second,
+		OptionEncoder(EncoderOptionPath(ffmpeg), EncoderOptionRoot(root), EncoderOptionRecipients(id.Recipient())),
+		OptionChannels(2),
+		OptionSplitChannels()).(*sampler)
+	if s.e.rawKey, err = age.GenerateX25519Identity(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := newRing(2, 8, PolicyBlock, s.spill, s.stats)
+	ts := time.Now()
+	c := chunk{raw: append(r.get(), 1, 2), ts: ts, channel: 1}
+	// the first chunk is spilled, the second encoded from memory
+	if err := s.spill(c); err != nil {
+		t.Fatal(err)
+	}
+	r.release(c)
+	c.raw, c.spilled = nil, true
+	r.put(c)
+	r.put(chunk{raw: append(r.get(), 3, 4), ts: ts, channel: 2})
+	r.close()
+	s.consume(r)
+
+	dirents, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, d := range dirents {
+		names = append(names, d.Name())
+	}
+	want := []string{
+		filepath.Base(s.e.outPath(ts, 1, outSealed)),
+		filepath.Base(s.e.outPath(ts, 2, outRawSealed)),
+	}
+	if !slices.Equal(names, want) {
+		t.Fatalf("got chunks %q, want %q", names, want)
+	}
+	for i, p := range want {
+		rc, err := OpenChunk(filepath.Join(root, p), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b[0] != byte(2*i+1) || len(b) != 2 {
+			t.Errorf("%s: decrypted %v", p, b)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package sampler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Piece is a chunk split from a recording, Start and End are its offsets in
+// the recording.
+type Piece struct {
+	Path       string
+	Start, End time.Duration
+}
+
+// Split cuts the recording input into flac chunks of period written to dir,
+// resampled to rate and downmixed like the recorded chunks. The pieces are
+// in clear whatever the recipients of e.
+func (e Encoder) Split(input, dir string, rate int, period time.Duration) ([]Piece, error) {
+	list := filepath.Join(dir, "pieces.csv")
+	args := []string{
+		"-i", input,
+		"-ar", strconv.Itoa(rate),
+		"-ac", "1",
+		"-map", "0:a",
+		"-c:a", "flac",
+		"-f", "segment",
+		"-segment_time", strconv.FormatFloat(period.Seconds(), 'f', -1, 64),
+		"-segment_list", list,
+		"-segment_list_type", "csv",
+		filepath.Join(dir, "%05d.flac"),
+	}
+	if out, err := exec.Command(e.ffmpegPath, args...).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("exec ffmpeg %q: %w: %s", input, err, lastLine(out))
+	}
+	f, err := os.Open(list)
+	if err != nil {
+		return nil, fmt.Errorf("open %q: %w", list, err)
+	}
+	defer f.Close()
+	// each record is the file name of a piece, its start and end in seconds
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 3
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read %q: %w", list, err)
+	}
+	pieces := make([]Piece, len(records))
+	for i, rec := range records {
+		start, err := strconv.ParseFloat(rec[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q start: %w", rec[0], err)
+		}
+		end, err := strconv.ParseFloat(rec[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q end: %w", rec[0], err)
+		}
+		pieces[i] = Piece{
+			Path:  filepath.Join(dir, filepath.Base(rec[0])),
+			Start: time.Duration(start * float64(time.Second)),
+			End:   time.Duration(end * float64(time.Second)),
+		}
+	}
+	return pieces, nil
+}
+
+// lastLine is the last non empty line of the ffmpeg output, its error.
+func lastLine(out []byte) []byte {
+	out = bytes.TrimRight(out, "\r\n")
+	return out[bytes.LastIndexByte(out, '\n')+1:]
+}
@@ -0,0 +1,44 @@
+package sampler
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSplit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake ffmpeg is a shell script")
+	}
+	dir := t.TempDir()
+	// the fake ffmpeg writes the segment list it is given
+	ffmpeg := filepath.Join(dir, "ffmpeg")
+	script := `#!/bin/sh
+while [ $# -gt 0 ]; do
+	if [ "$1" = -segment_list ]; then list=$2; fi
+	shift
+done
+printf '00000.flac,0.000000,10.000000\n00001.flac,10.000000,14.250000\n' > "$list"
+`
+	if err := os.WriteFile(ffmpeg, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	pieces, err := NewEncoder(EncoderOptionPath(ffmpeg)).Split("in.wav", dir, 16000, 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Piece{
+		{filepath.Join(dir, "00000.flac"), 0, 10 * time.Second},
+		{filepath.Join(dir, "00001.flac"), 10 * time.Second, 14250 * time.Millisecond},
+	}
+	if len(pieces) != len(want) {
+		t.Fatalf("got %d pieces, want %d", len(pieces), len(want))
+	}
+	for i := range want {
+		if pieces[i] != want[i] {
+			t.Errorf("piece %d: got %+v, want %+v", i, pieces[i], want[i])
+		}
+	}
+}
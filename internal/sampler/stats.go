@@ -0,0 +1,155 @@
+package sampler
+
+import (
+	"fmt"
+	"math"
+	"os/exec"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gordonklaus/portaudio"
+)
+
+// Stats are the sampler counters since it was created.
+type Stats struct {
+	Chunks          int       `json:"chunks"`
+	LastChunk       string    `json:"last_chunk,omitempty"`
+	LastChunkAt     time.Time `json:"last_chunk_at,omitzero"`
+	EncoderFailures int       `json:"encoder_failures"`
+	// Encode is how long the chunks took to encode.
+	Encode Latency `json:"encode"`
+	// DroppedChunks and DroppedFrames were dropped by the backpressure
+	// policy, SpilledChunks were spilled to disk.
+	DroppedChunks int `json:"dropped_chunks"`
+	DroppedFrames int `json:"dropped_frames"`
+	SpilledChunks int `json:"spilled_chunks"`
+	// Overflows counts the input buffers the device overflowed, their
+	// frames are lost.
+	Overflows int `json:"overflows"`
+	// Level is the RMS of the last input buffer, from 0 to 1.
+	Level float64 `json:"level"`
+	// Levels meters each track of the current sampling.
+	Levels []Level `json:"levels,omitempty"`
+}
+
+// Latency summarizes durations, they are marshaled in nanoseconds.
+type Latency struct {
+	Count int           `json:"count"`
+	Last  time.Duration `json:"last"`
+	Mean  time.Duration `json:"mean"`
+	Max   time.Duration `json:"max"`
+	total time.Duration
+}
+
+func (l *Latency) add(d time.Duration) {
+	l.Count++
+	l.Last = d
+	l.Max = max(l.Max, d)
+	l.total += d
+	l.Mean = l.total / time.Duration(l.Count)
+}
+
+type stats struct {
+	mu sync.Mutex
+	s  Stats
+}
+
+func (st *stats) chunk(path string, at time.Time) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.s.Chunks++
+	st.s.LastChunk = path
+	st.s.LastChunkAt = at
+}
+
+func (st *stats) encoded(took time.Duration) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.s.Encode.add(took)
+}
+
+func (st *stats) encoderFailure() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.s.EncoderFailures++
+}
+
+func (st *stats) dropped(frames int) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.s.DroppedChunks++
+	st.s.DroppedFrames += frames
+}
+
+func (st *stats) spilled() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.s.SpilledChunks++
+}
+
+func (st *stats) overflow() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.s.Overflows++
+}
+
+func (st *stats) level(l float64) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.s.Level = l
+}
+
+func (st *stats) levels(meters []*meter) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.s.Levels = st.s.Levels[:0]
+	for _, m := range meters {
+		st.s.Levels = append(st.s.Levels, m.level)
+	}
+}
+
+func (st *stats) get() Stats {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	s := st.s
+	s.Levels = slices.Clone(st.s.Levels)
+	return s
+}
+
+// rms is the root mean square of in scaled to [0, 1].
+func rms(in []int16) float64 {
+	if len(in) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range in {
+		f := float64(v) / math.MaxInt16
+		sum += f * f
+	}
+	return math.Sqrt(sum / float64(len(in)))
+}
+
+func (s sampler) Stats() Stats {
+	return s.stats.get()
+}
+
+func (s sampler) CheckDevice() (err error) {
+	if err := portaudio.Initialize(); err != nil {
+		return fmt.Errorf("portaudio initialize: %w", err)
+	}
+	defer func() {
+		if errTerminate := portaudio.Terminate(); err == nil && errTerminate != nil {
+			err = fmt.Errorf("portaudio terminate: %w", errTerminate)
+		}
+	}()
+	_, err = s.inputDevice()
+	return err
+}
+
+func (s sampler) CheckEncoder() error {
+	if _, err := exec.LookPath(s.e.ffmpegPath); err != nil {
+		return fmt.Errorf("ffmpeg %q: %w", s.e.ffmpegPath, err)
+	}
+	return nil
+}
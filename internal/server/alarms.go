@@ -0,0 +1,153 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/malikbenkirane/groq-whisper/internal/sampler"
+)
+
+// EventAlarm is the serf user event a node fires when an input alarm is
+// raised or cleared, so that groq-host warns the facilitator. Its payload
+// is an alarmPayload.
+const EventAlarm = "groq-alarm"
+
+// Alarms advertised in TagAlarms, suffixed with "/ch<n>" for a split
+// channel.
+const (
+	AlarmClipping = "clipping"
+	AlarmSilence  = "silence"
+)
+
+type alarmPayload struct {
+	Node    string `json:"node"`
+	Theme   string `json:"theme"`
+	Actor   string `json:"actor"`
+	Alarm   string `json:"alarm"`
+	Raised  bool   `json:"raised"`
+	Channel int    `json:"channel,omitempty"`
+	// DBFS is the rolling RMS of the track.
+	DBFS float64 `json:"dbfs"`
+}
+
+// alarms are the input alarms raised on the node.
+type alarms struct {
+	mu     sync.Mutex
+	raised map[string]struct{}
+	levels string
+}
+
+func newAlarms() *alarms {
+	return &alarms{raised: make(map[string]struct{})}
+}
+
+// alarmName is the name of an alarm in TagAlarms.
+func alarmName(alarm string, channel int) string {
+	if channel > 0 {
+		return fmt.Sprintf("%s/ch%d", alarm, channel)
+	}
+	return alarm
+}
+
+// watchLevels advertises the chunk levels in TagLevels and the alarms in
+// TagAlarms until events is unsubscribed, alarms are fired as EventAlarm.
+func (s server) watchLevels(events <-chan sampler.Event) {
+	for ev := range events {
+		switch ev.Kind {
+		case sampler.EventChunkWritten:
+			s.advertiseLevels(s.conf.sampler.Stats().Levels)
+		case sampler.EventClipping, sampler.EventClippingCleared:
+			s.alarm(ev, AlarmClipping, ev.Kind == sampler.EventClipping)
+		case sampler.EventSilence, sampler.EventSilenceCleared:
+			s.alarm(ev, AlarmSilence, ev.Kind == sampler.EventSilence)
+		case sampler.EventStopped:
+			s.clearAlarms()
+		}
+	}
+}
+
+// advertiseLevels sets TagLevels to the chunk RMS of each track in dBFS.
+func (s server) advertiseLevels(levels []sampler.Level) {
+	dbfs := make([]string, len(levels))
+	for i, l := range levels {
+		dbfs[i] = strconv.Itoa(int(math.Round(sampler.DBFS(l.ChunkRMS))))
+	}
+	tag := strings.Join(dbfs, ",")
+	s.alarms.mu.Lock()
+	defer s.alarms.mu.Unlock()
+	if tag == s.alarms.levels {
+		return
+	}
+	s.alarms.levels = tag
+	if err := s.setTag(TagLevels, tag); err != nil {
+		slog.Warn("serf: advertise levels", "err", err)
+	}
+}
+
+func (s server) alarm(ev sampler.Event, alarm string, raised bool) {
+	name := alarmName(alarm, ev.Channel)
+	if raised {
+		slog.Warn("input alarm raised", "alarm", name, "actor", ev.Actor, "dbfs", sampler.DBFS(ev.Level.RMS))
+	} else {
+		slog.Info("input alarm cleared", "alarm", name, "actor", ev.Actor)
+	}
+	s.alarms.mu.Lock()
+	if raised {
+		s.alarms.raised[name] = struct{}{}
+	} else {
+		delete(s.alarms.raised, name)
+	}
+	s.advertiseAlarms()
+	s.alarms.mu.Unlock()
+
+	actor := ev.Actor
+	if actor == "" && ev.Channel == 0 {
+		actor = s.serf.LocalMember().Tags[TagActor]
+	}
+	payload, err := json.Marshal(alarmPayload{
+		Node:    s.conf.name,
+		Theme:   s.conf.theme,
+		Actor:   actor,
+		Alarm:   alarm,
+		Raised:  raised,
+		Channel: ev.Channel,
+		DBFS:    sampler.DBFS(ev.Level.RMS),
+	})
+	if err != nil {
+		slog.Error("serf: alarm payload", "err", err)
+		return
+	}
+	if err := s.serf.UserEvent(EventAlarm, payload, false); err != nil {
+		slog.Warn("serf: fire alarm", "alarm", name, "err", err)
+	}
+}
+
+// clearAlarms resets the alarms and levels once the sampling stopped.
+func (s server) clearAlarms() {
+	s.alarms.mu.Lock()
+	defer s.alarms.mu.Unlock()
+	clear(s.alarms.raised)
+	s.advertiseAlarms()
+	s.alarms.levels = ""
+	if err := s.setTag(TagLevels, ""); err != nil {
+		slog.Warn("serf: advertise levels", "err", err)
+	}
+}
+
+// advertiseAlarms sets TagAlarms, the caller holds s.alarms.mu.
+func (s server) advertiseAlarms() {
+	names := make([]string, 0, len(s.alarms.raised))
+	for name := range s.alarms.raised {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	if err := s.setTag(TagAlarms, strings.Join(names, ",")); err != nil {
+		slog.Warn("serf: advertise alarms", "err", err)
+	}
+}
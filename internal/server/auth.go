@@ -0,0 +1,153 @@
+package server
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ReadTokens reads a bearer tokens file, one "identity token" pair per
+// line, blank lines and lines starting with # are skipped.
+func ReadTokens(p string) (map[string]string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("open %q: %w", p, err)
+	}
+	defer f.Close()
+	tokens := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// the fields are separated by spaces or tabs
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected \"identity token\", got %d fields", p, n, len(fields))
+		}
+		tokens[fields[1]] = fields[0]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %q: %w", p, err)
+	}
+	return tokens, nil
+}
+
+// tlsConfig requests client certificates signed by the client CA when it is
+// set, callers without one may still use a bearer token.
+func (c Config) tlsConfig() (*tls.Config, error) {
+	conf := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.clientCA == "" {
+		return conf, nil
+	}
+	pem, err := os.ReadFile(c.clientCA)
+	if err != nil {
+		return nil, fmt.Errorf("read client ca %q: %w", c.clientCA, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in %q", c.clientCA)
+	}
+	conf.ClientCAs = pool
+	conf.ClientAuth = tls.VerifyClientCertIfGiven
+	return conf, nil
+}
+
+// authRequired reports whether control requests must be authenticated.
+func (c Config) authRequired() bool {
+	return len(c.tokens) > 0 || c.clientCA != ""
+}
+
+// identify returns the caller identity and how it authenticated, ok is
+// false when the request carries no valid credential.
+func (c Config) identify(r *http.Request) (identity, method string, ok bool) {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		return certIdentity(r.TLS.VerifiedChains[0][0]), "mtls", true
+	}
+	if !c.authRequired() {
+		return "", "none", true
+	}
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "bearer") {
+		return "", "none", false
+	}
+	for known, identity := range c.tokens {
+		if subtle.ConstantTimeCompare([]byte(known), []byte(token)) == 1 {
+			return identity, "token", true
+		}
+	}
+	return "", "token", false
+}
+
+// certIdentity is the first host of a groq-host mkcert --client certificate,
+// or its subject.
+func certIdentity(cert *x509.Certificate) string {
+	switch {
+	case len(cert.DNSNames) > 0:
+		return cert.DNSNames[0]
+	case len(cert.IPAddresses) > 0:
+		return cert.IPAddresses[0].String()
+	case cert.Subject.CommonName != "":
+		return cert.Subject.CommonName
+	}
+	return cert.Subject.String()
+}
+
+// control authenticates a control request and writes its audit log line.
+func (s server) control(h customHandler) http.HandlerFunc {
+	handler := wrap(h)
+	return func(w http.ResponseWriter, r *http.Request) {
+		identity, method, ok := s.conf.identify(r)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		if ok {
+			handler(rec, r)
+		} else {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(rec, "unauthorized", http.StatusUnauthorized)
+		}
+		slog.Info("audit",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"caller", identity,
+			"auth", method,
+			"remote", r.RemoteAddr,
+			"status", rec.status)
+	}
+}
+
+// secured is control for the requests an unauthenticated api must not
+// serve, e.g. the gossip keyring: they are forbidden unless tokens or a
+// client CA are configured.
+func (s server) secured(h customHandler) http.HandlerFunc {
+	control := s.control(h)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.conf.authRequired() {
+			control(w, r)
+			return
+		}
+		http.Error(w, "forbidden without http.tokens_file or http.client_ca", http.StatusForbidden)
+		slog.Info("audit",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"auth", "none",
+			"remote", r.RemoteAddr,
+			"status", http.StatusForbidden)
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
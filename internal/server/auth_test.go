@@ -0,0 +1,115 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadTokens(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "tokens")
+	if err := os.WriteFile(p, []byte("# operators\nalice s3cret\n\nbob  other\ncarol\tthird\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := ReadTokens(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens["s3cret"] != "alice" || tokens["other"] != "bob" || tokens["third"] != "carol" || len(tokens) != 3 {
+		t.Errorf("unexpected tokens %v", tokens)
+	}
+	if err := os.WriteFile(p, []byte("dave token with spaces\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadTokens(p); err == nil {
+		t.Error("expected a line of 4 fields to be rejected")
+	}
+}
+
+func TestControl(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	ok := func(w http.ResponseWriter, r *http.Request) error { return nil }
+	for _, tc := range []struct {
+		name   string
+		conf   Config
+		header string
+		tls    *tls.ConnectionState
+		status int
+		audit  string
+	}{
+		{"no auth", Config{}, "", nil, http.StatusOK, "auth=none"},
+		{
+			"token", Config{tokens: map[string]string{"s3cret": "alice"}},
+			"Bearer s3cret", nil, http.StatusOK, "caller=alice auth=token",
+		},
+		{
+			"bad token", Config{tokens: map[string]string{"s3cret": "alice"}},
+			"Bearer guess", nil, http.StatusUnauthorized, "auth=token",
+		},
+		{
+			"missing token", Config{tokens: map[string]string{"s3cret": "alice"}},
+			"", nil, http.StatusUnauthorized, "auth=none",
+		},
+		{
+			"mtls", Config{clientCA: "ca.pem"}, "",
+			&tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{DNSNames: []string{"console"}}}}},
+			http.StatusOK, "caller=console auth=mtls",
+		},
+	} {
+		logs.Reset()
+		r := httptest.NewRequest(http.MethodPost, "/record", nil)
+		r.TLS = tc.tls
+		if tc.header != "" {
+			r.Header.Set("Authorization", tc.header)
+		}
+		w := httptest.NewRecorder()
+		server{conf: tc.conf}.control(ok)(w, r)
+		if w.Code != tc.status {
+			t.Errorf("%s: expected status %d got %d", tc.name, tc.status, w.Code)
+		}
+		if !strings.Contains(logs.String(), tc.audit) || !strings.Contains(logs.String(), "msg=audit") {
+			t.Errorf("%s: expected %q in audit line %q", tc.name, tc.audit, logs.String())
+		}
+	}
+}
+
+func TestSecured(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	called := false
+	ok := func(w http.ResponseWriter, r *http.Request) error {
+		called = true
+		return nil
+	}
+	r := httptest.NewRequest(http.MethodPost, "/keyring/install", nil)
+	w := httptest.NewRecorder()
+	server{conf: Config{}}.secured(ok)(w, r)
+	if w.Code != http.StatusForbidden || called {
+		t.Errorf("no auth: expected status %d got %d, handler called %t", http.StatusForbidden, w.Code, called)
+	}
+
+	conf := Config{tokens: map[string]string{"s3cret": "alice"}}
+	w = httptest.NewRecorder()
+	server{conf: conf}.secured(ok)(w, r)
+	if w.Code != http.StatusUnauthorized || called {
+		t.Errorf("missing token: expected status %d got %d", http.StatusUnauthorized, w.Code)
+	}
+	r.Header.Set("Authorization", "Bearer s3cret")
+	w = httptest.NewRecorder()
+	server{conf: conf}.secured(ok)(w, r)
+	if w.Code != http.StatusOK || !called {
+		t.Errorf("token: expected status %d got %d", http.StatusOK, w.Code)
+	}
+}
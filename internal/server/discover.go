@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/malikbenkirane/groq-whisper/internal/discovery"
+)
+
+// discoverRetry is how long discovery waits before looking the swarm up
+// again, until a peer is joined.
+const discoverRetry = 10 * time.Second
+
+// discover advertises the node serf port over mDNS and, without a master,
+// looks the swarm up until a peer is joined, or the node was joined by
+// one, so that nodes started together end in one swarm.
+func (s server) discover(ctx context.Context) error {
+	r, err := discovery.NewResponder(s.conf.name, s.conf.swarm, s.conf.port)
+	if err != nil {
+		return fmt.Errorf("mdns responder: %w", err)
+	}
+	go func() {
+		if err := r.Serve(ctx); err != nil {
+			slog.Error("mdns responder failed", "err", err)
+		}
+	}()
+	if s.conf.master != "" {
+		return nil
+	}
+	go func() {
+		t := time.NewTicker(discoverRetry)
+		defer t.Stop()
+		for !s.joinDiscovered(ctx) {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// joinDiscovered looks the swarm up and joins the peers found, it is true
+// once the node is part of a swarm.
+func (s server) joinDiscovered(ctx context.Context) bool {
+	if s.serf.NumNodes() > 1 {
+		return true
+	}
+	peers, err := discovery.Lookup(ctx, s.conf.swarm)
+	if err != nil {
+		slog.Warn("mdns lookup", "swarm", s.conf.swarm, "err", err)
+		return false
+	}
+	addrs := []string{}
+	for _, p := range peers {
+		if p.Node != s.conf.name {
+			addrs = append(addrs, p.Addr)
+		}
+	}
+	if len(addrs) == 0 {
+		slog.Info("mdns: no peer found, looking again", "swarm", s.conf.swarm, "in", discoverRetry)
+		return false
+	}
+	n, err := s.serf.Join(addrs, false)
+	if err != nil {
+		slog.Warn("mdns: serf join discovered peers", "peers", addrs, "err", err)
+		return false
+	}
+	slog.Info("mdns: joined swarm", "swarm", s.conf.swarm, "peers", addrs, "joined", n)
+	return true
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/hashicorp/serf/serf"
+)
+
+// GossipKeySize is the size of the keys made by NewGossipKey, AES-256.
+const GossipKeySize = 32
+
+var errPlaintext = errors.New("serf gossip is not encrypted (set a gossip key or allow plaintext)")
+
+// NewGossipKey returns a random base64 gossip key.
+func NewGossipKey() (string, error) {
+	b := make([]byte, GossipKeySize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("rand read: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// DecodeGossipKey decodes and validates a base64 gossip key.
+func DecodeGossipKey(key string) ([]byte, error) {
+	b, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("decode gossip key: %w", err)
+	}
+	if err := memberlist.ValidateKey(b); err != nil {
+		return nil, fmt.Errorf("gossip key: %w", err)
+	}
+	return b, nil
+}
+
+// keyring sets the memberlist keyring from the keyring file, or from the
+// gossip key when the file does not exist yet. Serf then persists key
+// rotations to the keyring file.
+func (c Config) keyring(sc *serf.Config) error {
+	var keys [][]byte
+	if c.keyringFile != "" {
+		b, err := os.ReadFile(c.keyringFile)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("read keyring %q: %w", c.keyringFile, err)
+		}
+		if err == nil {
+			var encoded []string
+			if err := json.Unmarshal(b, &encoded); err != nil {
+				return fmt.Errorf("decode keyring %q: %w", c.keyringFile, err)
+			}
+			for _, e := range encoded {
+				key, err := DecodeGossipKey(e)
+				if err != nil {
+					return fmt.Errorf("keyring %q: %w", c.keyringFile, err)
+				}
+				keys = append(keys, key)
+			}
+		}
+	}
+	if len(keys) == 0 && c.gossipKey != nil {
+		keys = [][]byte{c.gossipKey}
+	}
+	if len(keys) == 0 {
+		if !c.allowPlaintext {
+			return errPlaintext
+		}
+		slog.Warn("serf gossip is not encrypted")
+		return nil
+	}
+	kr, err := memberlist.NewKeyring(keys, keys[0])
+	if err != nil {
+		return fmt.Errorf("new keyring: %w", err)
+	}
+	sc.MemberlistConfig.Keyring = kr
+	sc.KeyringFile = c.keyringFile
+	return nil
+}
+
+// keyRequest is the body of the /keyring control requests.
+type keyRequest struct {
+	Key string `json:"key"`
+}
+
+// KeyResponse is the swarm answer to a keyring request.
+type KeyResponse struct {
+	Messages    map[string]string `json:"messages,omitempty"`
+	NumNodes    int               `json:"num_nodes"`
+	NumResp     int               `json:"num_resp"`
+	NumErr      int               `json:"num_err"`
+	Keys        map[string]int    `json:"keys,omitempty"`
+	PrimaryKeys map[string]int    `json:"primary_keys,omitempty"`
+}
+
+// handleKeyring runs op of the serf key manager, which asks every member
+// to apply it.
+func (s server) handleKeyring(op func(km *serf.KeyManager, key string) (*serf.KeyResponse, error)) customHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var req keyRequest
+		if r.Method != http.MethodGet {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				return fmt.Errorf("decode key request: %w", err)
+			}
+		}
+		res, err := op(s.serf.KeyManager(), req.Key)
+		if res == nil {
+			return fmt.Errorf("keyring: %w", err)
+		}
+		if err != nil {
+			slog.Warn("keyring: some members failed", "err", err)
+		}
+		w.Header().Add("Content-Type", "application/json")
+		return json.NewEncoder(w).Encode(KeyResponse{
+			Messages:    res.Messages,
+			NumNodes:    res.NumNodes,
+			NumResp:     res.NumResp,
+			NumErr:      res.NumErr,
+			Keys:        res.Keys,
+			PrimaryKeys: res.PrimaryKeys,
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/serf/serf"
+)
+
+func TestKeyring(t *testing.T) {
+	key := func() []byte {
+		encoded, err := NewGossipKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := DecodeGossipKey(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	k1, k2 := key(), key()
+
+	sc := serf.DefaultConfig()
+	if err := (Config{}).keyring(sc); !errors.Is(err, errPlaintext) {
+		t.Errorf("expected plaintext refusal got %v", err)
+	}
+	if err := (Config{allowPlaintext: true}).keyring(sc); err != nil || sc.MemberlistConfig.Keyring != nil {
+		t.Errorf("expected plaintext gossip got %v", err)
+	}
+
+	p := filepath.Join(t.TempDir(), "keyring")
+	sc = serf.DefaultConfig()
+	if err := (Config{gossipKey: k1, keyringFile: p}).keyring(sc); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sc.MemberlistConfig.Keyring.GetPrimaryKey(), k1) || sc.KeyringFile != p {
+		t.Error("expected the gossip key while the keyring file does not exist")
+	}
+
+	b, err := json.Marshal([]string{
+		base64.StdEncoding.EncodeToString(k2),
+		base64.StdEncoding.EncodeToString(k1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	sc = serf.DefaultConfig()
+	if err := (Config{gossipKey: k1, keyringFile: p}).keyring(sc); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sc.MemberlistConfig.Keyring.GetPrimaryKey(), k2) ||
+		len(sc.MemberlistConfig.Keyring.GetKeys()) != 2 {
+		t.Error("expected the keyring file keys with its first key as primary")
+	}
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/serf/serf"
+)
+
+// Member is a swarm member as last seen by this node.
+type Member struct {
+	Name      string            `json:"name"`
+	Addr      string            `json:"addr"`
+	Tags      map[string]string `json:"tags"`
+	Status    string            `json:"status"`
+	LastSeen  time.Time         `json:"last_seen"`
+	Recording bool              `json:"recording"`
+	// Levels are the dBFS of the last chunk of each track (TagLevels).
+	Levels []int `json:"levels,omitempty"`
+	// Alarms are the input alarms raised (TagAlarms).
+	Alarms []string `json:"alarms,omitempty"`
+}
+
+// members is the membership store written by the serf event loop and read
+// by the http handlers.
+type members struct {
+	mu     sync.RWMutex
+	byName map[string]Member
+}
+
+func newMembers() *members {
+	return &members{byName: make(map[string]Member)}
+}
+
+func (ms *members) update(m serf.Member, seen time.Time) {
+	tags := maps.Clone(m.Tags)
+	if tags == nil {
+		tags = make(map[string]string)
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.byName[m.Name] = Member{
+		Name:      m.Name,
+		Addr:      fmt.Sprintf("%s:%d", m.Addr, m.Port),
+		Tags:      tags,
+		Status:    m.Status.String(),
+		LastSeen:  seen,
+		Recording: tags[TagRecording] == "true",
+		Levels:    parseLevels(tags[TagLevels]),
+		Alarms:    split(tags[TagAlarms]),
+	}
+}
+
+// split splits a comma separated tag, nil when empty.
+func split(tag string) []string {
+	if tag == "" {
+		return nil
+	}
+	return strings.Split(tag, ",")
+}
+
+func parseLevels(tag string) []int {
+	var levels []int
+	for _, s := range split(tag) {
+		l, err := strconv.Atoi(s)
+		if err != nil {
+			return nil
+		}
+		levels = append(levels, l)
+	}
+	return levels
+}
+
+func (ms *members) remove(name string) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	delete(ms.byName, name)
+}
+
+// list returns copies of the members sorted by name.
+func (ms *members) list() []Member {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	l := make([]Member, 0, len(ms.byName))
+	for _, m := range ms.byName {
+		m.Tags = maps.Clone(m.Tags)
+		l = append(l, m)
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].Name < l[j].Name })
+	return l
+}
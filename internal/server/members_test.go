@@ -0,0 +1,77 @@
+package server
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/serf/serf"
+)
+
+func TestMembersConcurrent(t *testing.T) {
+	ms := newMembers()
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				ms.update(serf.Member{
+					Name:   "node-" + strconv.Itoa(i),
+					Addr:   net.IPv4(10, 0, 0, byte(i)),
+					Port:   7946,
+					Tags:   map[string]string{TagRecording: strconv.FormatBool(j%2 == 0)},
+					Status: serf.StatusAlive,
+				}, time.Now())
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				for _, m := range ms.list() {
+					m.Tags[TagActor] = "mutated copy"
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	l := ms.list()
+	if len(l) != 8 {
+		t.Fatalf("expected 8 members got %d", len(l))
+	}
+	for _, m := range l {
+		if _, ok := m.Tags[TagActor]; ok {
+			t.Errorf("%s: list leaked its tags", m.Name)
+		}
+	}
+}
+
+func TestMembersStatus(t *testing.T) {
+	ms := newMembers()
+	m := serf.Member{
+		Name:   "alpha",
+		Addr:   net.IPv4(10, 0, 0, 1),
+		Port:   7946,
+		Tags:   map[string]string{TagRecording: "true", TagActor: "alice"},
+		Status: serf.StatusAlive,
+	}
+	seen := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	ms.update(m, seen)
+	m.Status = serf.StatusFailed
+	ms.update(m, seen.Add(time.Minute))
+	l := ms.list()
+	if len(l) != 1 {
+		t.Fatalf("expected 1 member got %d", len(l))
+	}
+	got := l[0]
+	if got.Addr != "10.0.0.1:7946" || got.Status != "failed" || !got.Recording ||
+		!got.LastSeen.Equal(seen.Add(time.Minute)) || got.Tags[TagActor] != "alice" {
+		t.Errorf("unexpected member %+v", got)
+	}
+	ms.remove("alpha")
+	if l := ms.list(); len(l) != 0 {
+		t.Errorf("expected no member after remove got %d", len(l))
+	}
+}
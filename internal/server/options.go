@@ -3,28 +3,41 @@ package server
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"os"
 
 	"github.com/malikbenkirane/groq-whisper/internal/sampler"
 )
 
 func defaultConfig(root string) (Config, error) {
-	b := make([]byte, 8)
-	_, err := rand.Read(b)
+	name, err := defaultName()
 	if err != nil {
 		return Config{}, err
 	}
 	s := sampler.DefaultSys32(root)
 	return Config{
-		http:      ":50002",
+		http:      ":7495",
 		port:      7946,
 		master:    "192.168.117.1:7496",
-		name:      base64.StdEncoding.EncodeToString(b),
+		name:      name,
 		startHttp: true,
 		startLoop: true,
 		sampler:   s,
 	}, nil
 }
 
+// defaultName is the host name so that groq-host bindings survive restarts,
+// or a random name when the host name is unknown.
+func defaultName() (string, error) {
+	if name, err := os.Hostname(); err == nil && name != "" {
+		return name, nil
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
 type Option func(Config) Config
 
 func OptionSerfPort(port int) Option {
@@ -48,6 +61,56 @@ func OptionSerfName(name string) Option {
 	}
 }
 
+// OptionActor advertises the actor speaking into this node's microphone.
+func OptionActor(name string) Option {
+	return func(c Config) Config {
+		c.actor = name
+		return c
+	}
+}
+
+// OptionTheme advertises the theme this node records for.
+func OptionTheme(name string) Option {
+	return func(c Config) Config {
+		c.theme = name
+		return c
+	}
+}
+
+// OptionGossipKey encrypts the serf gossip with key (see DecodeGossipKey).
+func OptionGossipKey(key []byte) Option {
+	return func(c Config) Config {
+		c.gossipKey = key
+		return c
+	}
+}
+
+// OptionKeyringFile persists the gossip keys to p so that rotations survive
+// restarts, the keys of p win over OptionGossipKey.
+func OptionKeyringFile(p string) Option {
+	return func(c Config) Config {
+		c.keyringFile = p
+		return c
+	}
+}
+
+// OptionAllowPlaintext lets the node gossip unencrypted.
+func OptionAllowPlaintext() Option {
+	return func(c Config) Config {
+		c.allowPlaintext = true
+		return c
+	}
+}
+
+// OptionSwarm advertises the node over mDNS as a member of swarm and, when
+// no master is set, joins the members already advertising it.
+func OptionSwarm(swarm string) Option {
+	return func(c Config) Config {
+		c.swarm = swarm
+		return c
+	}
+}
+
 func OptionHttpAddr(addr string) Option {
 	return func(c Config) Config {
 		c.http = addr
@@ -75,3 +138,30 @@ func OptionNoLoop() Option {
 		return c
 	}
 }
+
+// OptionTLS serves the http api over tls, e.g. with the certificate made by
+// groq-host mkcert.
+func OptionTLS(certFile, keyFile string) Option {
+	return func(c Config) Config {
+		c.certFile, c.keyFile = certFile, keyFile
+		return c
+	}
+}
+
+// OptionClientCA authenticates control requests with client certificates
+// signed by caFile, it requires OptionTLS.
+func OptionClientCA(caFile string) Option {
+	return func(c Config) Config {
+		c.clientCA = caFile
+		return c
+	}
+}
+
+// OptionTokens authenticates control requests with bearer tokens, tokens
+// maps each token to its caller identity (see ReadTokens).
+func OptionTokens(tokens map[string]string) Option {
+	return func(c Config) Config {
+		c.tokens = tokens
+		return c
+	}
+}
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/serf/serf"
+	"github.com/malikbenkirane/groq-whisper/internal/sampler"
+)
+
+// EventRecord is the serf user event groq-host fires when a session of a
+// theme starts or stops, nodes advertising that theme start or stop their
+// sampler. Its payload is a recordPayload.
+const EventRecord = "groq-record"
+
+// QueryRecord is the serf query groq-host sends right after EventRecord to
+// collect acknowledgements. Nodes that missed the event apply it on the
+// query, the response is a recordAck.
+const QueryRecord = "groq-record-ack"
+
+// Record actions.
+const (
+	ActionStart = "start"
+	ActionStop  = "stop"
+)
+
+type recordPayload struct {
+	Theme   string `json:"theme"`
+	Session int    `json:"session"`
+	Action  string `json:"action"`
+	// Epoch is when the groq-host run started in unix nanoseconds, the
+	// session ids grow within an epoch only.
+	Epoch int64 `json:"epoch,omitempty"`
+}
+
+type recordAck struct {
+	Node      string    `json:"node"`
+	Theme     string    `json:"theme"`
+	Session   int       `json:"session"`
+	Recording bool      `json:"recording"`
+	Since     time.Time `json:"since"`
+	Err       string    `json:"err,omitempty"`
+}
+
+// recording is the last record request applied by the node, session and
+// epoch are zero once the node was started or stopped through its api.
+type recording struct {
+	mu      sync.Mutex
+	on      bool
+	session int
+	epoch   int64
+	since   time.Time
+	// err is why the last sampling failed.
+	err string
+}
+
+// stale reports whether p was overtaken by the last request applied: it
+// comes from an older groq-host run, or targets an older session of the
+// same run. A late or re-delivered event must not stop the next session,
+// the ids of a new run, e.g. on another database, may restart lower.
+func (r *recording) stale(p recordPayload) bool {
+	if p.Epoch != r.epoch {
+		return p.Epoch < r.epoch
+	}
+	return p.Session < r.session
+}
+
+// manual applies sig asked through the node api, the next groq-host
+// request holds whatever its session.
+func (s server) manual(sig signal) error {
+	s.rec.mu.Lock()
+	defer s.rec.mu.Unlock()
+	if err := s.apply(sig); err != nil {
+		return err
+	}
+	s.rec.session, s.rec.epoch = 0, 0
+	return nil
+}
+
+// recordQueue is how many record events and queries wait for the ones
+// being applied.
+const recordQueue = 64
+
+// signalTimeout bounds how long a record request waits for the sampling
+// loop.
+const signalTimeout = 5 * time.Second
+
+// record applies p when it targets the node theme, ok is false otherwise.
+func (s server) record(p recordPayload) (ack recordAck, ok bool, err error) {
+	if !s.conf.startLoop || s.conf.theme == "" || p.Theme != s.conf.theme {
+		return recordAck{}, false, nil
+	}
+	var sig signal
+	switch p.Action {
+	case ActionStart:
+		sig = signalStart
+	case ActionStop:
+		sig = signalStop
+	default:
+		return recordAck{}, true, fmt.Errorf("unknown record action %q", p.Action)
+	}
+	s.rec.mu.Lock()
+	defer s.rec.mu.Unlock()
+	if s.rec.stale(p) {
+		slog.Info("serf: stale record ignored", "action", p.Action, "session", p.Session, "recording", s.rec.session)
+	} else {
+		if s.rec.on != (sig == signalStart) {
+			if err := s.apply(sig); err != nil {
+				return recordAck{}, true, err
+			}
+		}
+		s.rec.session, s.rec.epoch = p.Session, p.Epoch
+	}
+	return recordAck{
+		Node:      s.conf.name,
+		Theme:     s.conf.theme,
+		Session:   s.rec.session,
+		Recording: s.rec.on,
+		Since:     s.rec.since,
+	}, true, nil
+}
+
+// apply sends sig to the sampling loop and advertises the new state in
+// TagRecording, the caller holds s.rec.mu.
+func (s server) apply(sig signal) error {
+	req := request{sig: sig, reply: make(chan error, 1)}
+	timeout := time.After(signalTimeout)
+	select {
+	case s.sig <- req:
+	case <-timeout:
+		return fmt.Errorf("sampling loop busy")
+	}
+	select {
+	case err := <-req.reply:
+		if err != nil {
+			return err
+		}
+	case <-timeout:
+		return fmt.Errorf("sampling loop busy")
+	}
+	s.rec.on = sig == signalStart
+	s.rec.since = time.Now()
+	if s.rec.on {
+		s.rec.err = ""
+	}
+	if err := s.setTag(TagRecording, strconv.FormatBool(s.rec.on)); err != nil {
+		slog.Warn("serf: advertise recording", "err", err)
+	}
+	return nil
+}
+
+// samplingFailed records err and advertises the node is not recording
+// anymore.
+func (s server) samplingFailed(err error) {
+	slog.Error("sampling failed", "err", err)
+	s.rec.mu.Lock()
+	defer s.rec.mu.Unlock()
+	s.rec.err = err.Error()
+	if !s.rec.on {
+		return
+	}
+	s.rec.on = false
+	s.rec.since = time.Now()
+	if err := s.setTag(TagRecording, "false"); err != nil {
+		slog.Warn("serf: advertise recording", "err", err)
+	}
+}
+
+// logSampler logs the sampler events until unsubscribed.
+func logSampler(events <-chan sampler.Event) {
+	for ev := range events {
+		level := slog.LevelInfo
+		if ev.Err != nil {
+			level = slog.LevelWarn
+		}
+		slog.Log(context.Background(), level, "sampler: "+ev.Kind.String(),
+			"chunk", ev.Chunk, "channel", ev.Channel, "actor", ev.Actor, "err", ev.Err)
+	}
+}
+
+// handleRecords applies the record events and queries one at a time, in
+// the order they were gossiped, until ctx is done.
+func (s server) handleRecords(ctx context.Context) {
+	for {
+		select {
+		case handle := <-s.records:
+			handle()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s server) handleRecord(ev serf.UserEvent) {
+	var p recordPayload
+	if err := json.Unmarshal(ev.Payload, &p); err != nil {
+		slog.Warn("serf: bad record payload", "err", err)
+		return
+	}
+	if _, ok, err := s.record(p); err != nil {
+		slog.Error("serf: record", "action", p.Action, "session", p.Session, "err", err)
+	} else if ok {
+		slog.Info("serf: record", "action", p.Action, "session", p.Session)
+	}
+}
+
+func (s server) handleRecordQuery(q *serf.Query) {
+	var p recordPayload
+	if err := json.Unmarshal(q.Payload, &p); err != nil {
+		slog.Warn("serf: bad record query payload", "err", err)
+		return
+	}
+	ack, ok, err := s.record(p)
+	if !ok {
+		return
+	}
+	if err != nil {
+		ack = recordAck{Node: s.conf.name, Theme: s.conf.theme, Session: p.Session, Err: err.Error()}
+	}
+	b, err := json.Marshal(ack)
+	if err != nil {
+		slog.Error("serf: encode record ack", "err", err)
+		return
+	}
+	if err := q.Respond(b); err != nil {
+		slog.Error("serf: respond record query", "err", err)
+	}
+}
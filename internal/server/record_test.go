@@ -0,0 +1,34 @@
+package server
+
+import "testing"
+
+func TestRecordingStale(t *testing.T) {
+	const epoch = 100
+	rec := &recording{on: true, session: 8, epoch: epoch}
+	for _, tc := range []struct {
+		name  string
+		p     recordPayload
+		stale bool
+	}{
+		{"late stop", recordPayload{Session: 7, Action: ActionStop, Epoch: epoch}, true},
+		{"late start", recordPayload{Session: 7, Action: ActionStart, Epoch: epoch}, true},
+		{"start again", recordPayload{Session: 8, Action: ActionStart, Epoch: epoch}, false},
+		{"stop", recordPayload{Session: 8, Action: ActionStop, Epoch: epoch}, false},
+		{"stop of a newer session", recordPayload{Session: 9, Action: ActionStop, Epoch: epoch}, false},
+		{"next session", recordPayload{Session: 9, Action: ActionStart, Epoch: epoch}, false},
+		// a restarted host on a new database counts from 1 again
+		{"new host run", recordPayload{Session: 1, Action: ActionStop, Epoch: epoch + 1}, false},
+		{"old host run", recordPayload{Session: 9, Action: ActionStop, Epoch: epoch - 1}, true},
+	} {
+		if got := rec.stale(tc.p); got != tc.stale {
+			t.Errorf("%s: got stale %t", tc.name, got)
+		}
+	}
+
+	// a node started through its api before the host session began stops
+	// on the host stop
+	rec = &recording{on: true}
+	if rec.stale(recordPayload{Session: 12, Action: ActionStop, Epoch: epoch}) {
+		t.Error("host stop of a manually started node is stale")
+	}
+}
@@ -3,9 +3,12 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/serf/serf"
 	"github.com/malikbenkirane/groq-whisper/internal/sampler"
@@ -14,7 +17,10 @@ import (
 func New(root string, opts ...Option) (Server, error) {
 
 	sv := &server{}
-	sv.swarm = make(map[string]bool)
+	sv.members = newMembers()
+	sv.rec = &recording{}
+	sv.alarms = newAlarms()
+	sv.tagMu = &sync.Mutex{}
 
 	c, err := defaultConfig(root)
 	if err != nil {
@@ -29,15 +35,20 @@ func New(root string, opts ...Option) (Server, error) {
 	conf := serf.DefaultConfig()
 	conf.Init()
 	conf.NodeName = c.name
+	conf.Tags = c.tags()
 	conf.MemberlistConfig.BindAddr = "0.0.0.0"
 	conf.MemberlistConfig.BindPort = c.port
 	conf.EventCh = events
 	sv.serfCh = events
+	if err := c.keyring(conf); err != nil {
+		return nil, err
+	}
 
 	slog.Info("serf config",
 		"bind_addr", conf.MemberlistConfig.BindAddr,
 		"bind_port", conf.MemberlistConfig.BindPort,
 		"node_name", conf.NodeName,
+		"tags", conf.Tags,
 		"join_addr", c.master)
 
 	instance, err := serf.Create(conf)
@@ -46,37 +57,61 @@ func New(root string, opts ...Option) (Server, error) {
 	}
 	sv.serf = instance
 
-	sig := make(chan signal)
-	sv.sig = sig
+	sv.sig = make(chan request)
+	sv.records = make(chan func(), recordQueue)
 
 	mux := http.NewServeMux()
-	mux.Handle("POST /record", wrap(func(w http.ResponseWriter, r *http.Request) (err error) {
+	mux.Handle("POST /record", sv.control(func(w http.ResponseWriter, r *http.Request) (err error) {
 		slog.Info("POST /record")
-		sig <- signalStart
+		if err := sv.manual(signalStart); err != nil {
+			return err
+		}
 		slog.Info("signaled start")
 		return nil
 	}))
-	mux.Handle("DELETE /record", wrap(func(w http.ResponseWriter, r *http.Request) (err error) {
+	mux.Handle("GET /record", wrap(sv.handleGetRecord))
+	// the keyring lists and replaces the gossip keys, a caller of an open
+	// api would take over the swarm
+	mux.Handle("GET /keyring", sv.secured(sv.handleKeyring(
+		func(km *serf.KeyManager, _ string) (*serf.KeyResponse, error) { return km.ListKeys() })))
+	mux.Handle("POST /keyring/install", sv.secured(sv.handleKeyring((*serf.KeyManager).InstallKey)))
+	mux.Handle("POST /keyring/use", sv.secured(sv.handleKeyring((*serf.KeyManager).UseKey)))
+	mux.Handle("POST /keyring/remove", sv.secured(sv.handleKeyring((*serf.KeyManager).RemoveKey)))
+	mux.Handle("GET /healthz", wrap(sv.handleHealthz))
+	mux.Handle("GET /readyz", wrap(sv.handleReadyz))
+	mux.Handle("DELETE /record", sv.control(func(w http.ResponseWriter, r *http.Request) (err error) {
 		slog.Info("DELETE /record")
-		sig <- signalStop
+		if err := sv.manual(signalStop); err != nil {
+			return err
+		}
 		slog.Info("signaled stop")
 		return nil
 	}))
 	mux.Handle("GET /state", wrap(func(w http.ResponseWriter, r *http.Request) (err error) {
+		w.Header().Add("Content-Type", "application/json")
 		encoder := json.NewEncoder(w)
 		encoder.SetIndent("", "  ")
-		return encoder.Encode(sv.swarm)
+		return encoder.Encode(sv.members.list())
 	}))
 	sv.mux = mux
+	sv.http = &http.Server{
+		Addr:    c.http,
+		Handler: mux,
+	}
 
 	return sv, nil
 
 }
 
 type Server interface {
+	// Serve returns once ctx is done and the node has stopped sampling,
+	// left the swarm and shut its http server down.
 	Serve(ctx context.Context)
 }
 
+// shutdownTimeout bounds the http server shutdown and the serf leave.
+const shutdownTimeout = 10 * time.Second
+
 type signal int
 
 const (
@@ -84,12 +119,36 @@ const (
 	signalStop
 )
 
+// request is a signal to the sampling loop, reply receives nil once the
+// signal is applied or the reason it was not.
+type request struct {
+	sig   signal
+	reply chan error
+}
+
+var (
+	errSampling    = errors.New("sampler already sampling")
+	errNotSampling = errors.New("sampler not sampling")
+)
+
 type Config struct {
 	port   int
 	master string
 	name   string
 	http   string
 
+	actor string
+	theme string
+	swarm string
+
+	gossipKey      []byte
+	keyringFile    string
+	allowPlaintext bool
+
+	certFile, keyFile string
+	clientCA          string
+	tokens            map[string]string
+
 	startHttp bool
 	startLoop bool
 
@@ -98,34 +157,48 @@ type Config struct {
 
 type server struct {
 	mux    *http.ServeMux
+	http   *http.Server
 	serf   *serf.Serf
 	serfCh chan serf.Event
 	conf   Config
-	sig    chan signal
+	sig    chan request
+	rec    *recording
+	tagMu  *sync.Mutex
 
-	swarm map[string]bool
+	members *members
+	alarms  *alarms
+	// records are the record events and queries, handled in order.
+	records chan func()
 }
 
 func (s server) Serve(ctx context.Context) {
+	// ech is never closed, the goroutines serving may still fail while
+	// Serve returns
 	ech := make(chan error)
-	defer close(ech)
 	go func() {
-		for {
-			select {
-			case e, ok := <-ech:
-				if !ok {
-					slog.Info("err chan was closed")
-				}
-				slog.Error("serve messed up", "err", e)
-			case <-ctx.Done():
-				slog.Info("err check routine: context done")
-			}
+		for e := range ech {
+			slog.Error("serve messed up", "err", e)
 		}
 	}()
+	defer s.shutdown()
 	if s.conf.startHttp {
 		go s.serveHttp(ech)
 	}
-	go s.gossip()
+	go s.gossip(ctx)
+	go s.handleRecords(ctx)
+	if s.conf.startLoop {
+		events, unsubscribe := s.conf.sampler.Subscribe()
+		defer unsubscribe()
+		go logSampler(events)
+		levels, unsubscribeLevels := s.conf.sampler.Subscribe()
+		defer unsubscribeLevels()
+		go s.watchLevels(levels)
+	}
+	if s.conf.swarm != "" {
+		if err := s.discover(ctx); err != nil {
+			ech <- err
+		}
+	}
 	if s.conf.master != "" {
 		_, err := s.serf.Join([]string{s.conf.master}, false)
 		if err != nil {
@@ -136,67 +209,168 @@ func (s server) Serve(ctx context.Context) {
 		<-ctx.Done()
 		return
 	}
-	sampling := false
 	var (
-		scope  context.Context
-		cancel context.CancelFunc
+		cancel  context.CancelFunc
+		sampled chan struct{}
 	)
+	defer func() {
+		if cancel != nil {
+			cancel()
+		}
+		if sampled != nil {
+			<-sampled
+		}
+	}()
 loop:
 	for {
 		select {
-		case signal, ok := <-s.sig:
+		case req, ok := <-s.sig:
 			if !ok {
 				slog.Info("sig channel closed")
 				return
 			}
-			switch signal {
+			if cancel != nil && closed(sampled) {
+				// the sampling failed
+				cancel()
+				cancel = nil
+			}
+			switch req.sig {
 			case signalStart:
-				if sampling {
+				if cancel != nil {
 					slog.Warn("sampler asked to start twice")
+					req.reply <- errSampling
 					continue loop
 				}
-				scope, cancel = context.WithCancel(ctx)
-				defer cancel()
-				go s.conf.sampler.Sample(scope)
-				sampling = true
+				scope, stop := context.WithCancel(ctx)
+				cancel = stop
+				prev := sampled
+				sampled = make(chan struct{})
+				go func(done chan struct{}) {
+					defer close(done)
+					// the previous sampling flushes its last chunk first
+					if prev != nil {
+						<-prev
+					}
+					if err := s.conf.sampler.Sample(scope); err != nil {
+						s.samplingFailed(err)
+					}
+				}(sampled)
 			case signalStop:
-				if !sampling {
+				if cancel == nil {
 					slog.Warn("sampler asked to stop twice")
+					req.reply <- errNotSampling
 					continue loop
 				}
 				cancel()
-				sampling = false
+				cancel = nil
 			}
+			req.reply <- nil
 		case <-ctx.Done():
-			slog.Info("serve done")
+			slog.Info("serve done, waiting for the sampler")
 			return
 		}
 	}
 }
 
+func closed(c <-chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
+// shutdown leaves the swarm so peers see a left member rather than a failed
+// one, and drains the http server.
+func (s server) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if s.conf.startHttp {
+		if err := s.http.Shutdown(ctx); err != nil {
+			slog.Warn("http shutdown", "err", err)
+		}
+	}
+	if err := s.serf.Leave(); err != nil {
+		slog.Warn("serf leave", "err", err)
+	}
+	if err := s.serf.Shutdown(); err != nil {
+		slog.Warn("serf shutdown", "err", err)
+	}
+	slog.Info("server stopped")
+}
+
 func (s server) serveHttp(err chan error) {
-	err <- http.ListenAndServe(s.conf.http, s.mux)
+	var errServe error
+	if s.conf.certFile == "" {
+		if s.conf.authRequired() {
+			slog.Warn("http: bearer tokens are sent in clear without tls")
+		}
+		errServe = s.http.ListenAndServe()
+	} else {
+		tlsConfig, errTls := s.conf.tlsConfig()
+		if errTls != nil {
+			err <- errTls
+			return
+		}
+		s.http.TLSConfig = tlsConfig
+		errServe = s.http.ListenAndServeTLS(s.conf.certFile, s.conf.keyFile)
+	}
+	if !errors.Is(errServe, http.ErrServerClosed) {
+		err <- errServe
+	}
 }
 
-func (s server) gossip() {
+// gossip handles the serf events, the record ones are queued to
+// handleRecords until ctx is done.
+func (s server) gossip(ctx context.Context) {
+	queue := func(handle func()) {
+		select {
+		case s.records <- handle:
+		case <-ctx.Done():
+		}
+	}
 	for e := range s.serfCh {
 		switch ev := e.(type) {
 		case serf.MemberEvent:
+			now := time.Now()
 			for _, m := range ev.Members {
 				switch ev.EventType() {
 				case serf.EventMemberJoin:
 					slog.Info("serf: member joined", "name", m.Name, "addr", m.Addr)
-					s.swarm[m.Addr.String()] = true
+					s.members.update(m, now)
 				case serf.EventMemberFailed:
 					slog.Warn("serf: member failed", "name", m.Name, "addr", m.Addr)
-					s.swarm[m.Addr.String()] = false
+					s.members.update(m, now)
 				case serf.EventMemberLeave:
 					slog.Info("serf: member leaved", "name", m.Name, "addr", m.Addr)
-					s.swarm[m.Addr.String()] = false
+					s.members.update(m, now)
+				case serf.EventMemberUpdate:
+					slog.Info("serf: member updated", "name", m.Name, "tags", m.Tags)
+					s.members.update(m, now)
+				case serf.EventMemberReap:
+					slog.Info("serf: member reaped", "name", m.Name)
+					s.members.remove(m.Name)
 				default:
 					slog.Info("serf: other event", "type", ev.EventType(), "from", m.Name)
 				}
 			}
+		case serf.UserEvent:
+			switch ev.Name {
+			case EventBind:
+				s.handleBind(ev)
+			case EventRecord:
+				queue(func() { s.handleRecord(ev) })
+			default:
+				slog.Info("serf: other user event", "name", ev.Name)
+			}
+		case *serf.Query:
+			switch ev.Name {
+			case QueryRecord:
+				queue(func() { s.handleRecordQuery(ev) })
+			default:
+				slog.Info("serf: other query", "name", ev.Name)
+			}
 		}
 	}
 }
@@ -206,7 +380,12 @@ type customHandler func(w http.ResponseWriter, r *http.Request) (err error)
 func wrap(h customHandler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if err := h(w, r); err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+			slog.Warn("http handler failed", "path", r.URL.Path, "err", err)
+			code := http.StatusInternalServerError
+			if errors.Is(err, errSampling) || errors.Is(err, errNotSampling) {
+				code = http.StatusConflict
+			}
+			http.Error(w, err.Error(), code)
 		}
 	}
 }
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/malikbenkirane/groq-whisper/internal/sampler"
+)
+
+// RecordStatus is the body of GET /record.
+type RecordStatus struct {
+	Recording bool      `json:"recording"`
+	Since     time.Time `json:"since,omitzero"`
+	Session   int       `json:"session,omitempty"`
+	// Actor is the actor bound to the node, speaking into its mono chunks.
+	Actor string `json:"actor,omitempty"`
+	// Err is why the last sampling failed.
+	Err string `json:"err,omitempty"`
+	sampler.Stats
+}
+
+// Check is the body of GET /healthz and GET /readyz, Checks maps each
+// check to "ok" or its failure.
+type Check struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func (s server) handleGetRecord(w http.ResponseWriter, r *http.Request) error {
+	s.rec.mu.Lock()
+	status := RecordStatus{
+		Recording: s.rec.on,
+		Since:     s.rec.since,
+		Session:   s.rec.session,
+		Err:       s.rec.err,
+	}
+	s.rec.mu.Unlock()
+	if s.serf != nil {
+		status.Actor = s.serf.LocalMember().Tags[TagActor]
+	}
+	status.Stats = s.conf.sampler.Stats()
+	w.Header().Add("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(status)
+}
+
+// handleHealthz reports the node is serving, it does not check the sampler.
+func (s server) handleHealthz(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Add("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(Check{Status: "ok"})
+}
+
+// handleReadyz reports whether the node can sample: an input device is
+// present and the encoder can be run.
+func (s server) handleReadyz(w http.ResponseWriter, r *http.Request) error {
+	c := Check{Status: "ok", Checks: make(map[string]string)}
+	for name, check := range map[string]func() error{
+		"device":  s.conf.sampler.CheckDevice,
+		"encoder": s.conf.sampler.CheckEncoder,
+	} {
+		c.Checks[name] = "ok"
+		if err := check(); err != nil {
+			c.Checks[name] = err.Error()
+			c.Status = "failed"
+		}
+	}
+	w.Header().Add("Content-Type", "application/json")
+	if c.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	return json.NewEncoder(w).Encode(c)
+}
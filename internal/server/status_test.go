@@ -0,0 +1,97 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/malikbenkirane/groq-whisper/internal/sampler"
+)
+
+// fakeSampler reports stats and checks, it never samples.
+type fakeSampler struct {
+	sampler.Sampler
+	stats  sampler.Stats
+	device error
+}
+
+func (f fakeSampler) Stats() sampler.Stats { return f.stats }
+func (f fakeSampler) CheckDevice() error   { return f.device }
+func (f fakeSampler) CheckEncoder() error  { return nil }
+
+func TestGetRecord(t *testing.T) {
+	since := time.Date(2026, 10, 19, 14, 58, 0, 0, time.UTC)
+	s := server{
+		conf: Config{sampler: fakeSampler{stats: sampler.Stats{Chunks: 3, LastChunk: "20261019145830,000.flac"}}},
+		rec:  &recording{on: true, session: 8, since: since},
+	}
+	w := httptest.NewRecorder()
+	wrap(s.handleGetRecord)(w, httptest.NewRequest(http.MethodGet, "/record", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("expected a json 200 got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	var status RecordStatus
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if !status.Recording || status.Session != 8 || !status.Since.Equal(since) ||
+		status.Chunks != 3 || status.LastChunk != "20261019145830,000.flac" || status.Err != "" {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	// a failed sampling is reported once stopped
+	s.rec = &recording{err: "stream read: device lost"}
+	w = httptest.NewRecorder()
+	wrap(s.handleGetRecord)(w, httptest.NewRequest(http.MethodGet, "/record", nil))
+	status = RecordStatus{}
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if status.Recording || status.Err != "stream read: device lost" || !status.Since.IsZero() {
+		t.Errorf("unexpected stopped status %+v", status)
+	}
+}
+
+func TestHealthz(t *testing.T) {
+	// the node serves whatever the sampler
+	s := server{conf: Config{sampler: fakeSampler{device: errors.New("no input device")}}}
+	w := httptest.NewRecorder()
+	wrap(s.handleHealthz)(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	var c Check
+	if err := json.NewDecoder(w.Body).Decode(&c); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || c.Status != "ok" {
+		t.Errorf("expected ok got %d %+v", w.Code, c)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		device error
+		status int
+		check  Check
+	}{
+		{"ready", nil, http.StatusOK, Check{Status: "ok", Checks: map[string]string{"device": "ok", "encoder": "ok"}}},
+		{
+			"no device", errors.New("no input device"), http.StatusServiceUnavailable,
+			Check{Status: "failed", Checks: map[string]string{"device": "no input device", "encoder": "ok"}},
+		},
+	} {
+		s := server{conf: Config{sampler: fakeSampler{device: tc.device}}}
+		w := httptest.NewRecorder()
+		wrap(s.handleReadyz)(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var c Check
+		if err := json.NewDecoder(w.Body).Decode(&c); err != nil {
+			t.Fatalf("%s: %s", tc.name, err)
+		}
+		if w.Code != tc.status || c.Status != tc.check.Status ||
+			c.Checks["device"] != tc.check.Checks["device"] || c.Checks["encoder"] != tc.check.Checks["encoder"] {
+			t.Errorf("%s: expected %d %+v got %d %+v", tc.name, tc.status, tc.check, w.Code, c)
+		}
+	}
+}
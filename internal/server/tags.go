@@ -0,0 +1,101 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/hashicorp/serf/serf"
+	"github.com/malikbenkirane/groq-whisper/setup/pkg/version"
+)
+
+// Serf tags advertised by recorder nodes, groq-host reads them to map
+// members to actors.
+const (
+	TagActor   = "actor"
+	TagTheme   = "theme"
+	TagVersion = "version"
+	TagCaps    = "caps"
+	// TagRecording is "true" while the node samples.
+	TagRecording = "recording"
+	// TagLevels lists the RMS of the last chunk of each track in dBFS,
+	// e.g. "-23,-41".
+	TagLevels = "levels"
+	// TagAlarms lists the input alarms raised, e.g. "silence/ch2".
+	TagAlarms = "alarms"
+)
+
+// Capabilities listed in TagCaps.
+const (
+	CapRecord = "record"
+	CapHttp   = "http"
+)
+
+// EventBind is the serf user event groq-host fires to (re)assign the actor
+// speaking into a node's microphone, its payload is a bindPayload.
+const EventBind = "groq-bind"
+
+type bindPayload struct {
+	Node  string `json:"node"`
+	Actor string `json:"actor"`
+}
+
+func (c Config) tags() map[string]string {
+	caps := []string{}
+	if c.startLoop {
+		caps = append(caps, CapRecord)
+	}
+	if c.startHttp {
+		caps = append(caps, CapHttp)
+	}
+	tags := map[string]string{
+		TagVersion: version.Version,
+		TagCaps:    strings.Join(caps, ","),
+	}
+	if c.startLoop {
+		tags[TagRecording] = "false"
+	}
+	if c.actor != "" {
+		tags[TagActor] = c.actor
+	}
+	if c.theme != "" {
+		tags[TagTheme] = c.theme
+	}
+	return tags
+}
+
+// setTag updates one tag of the local member, an empty value removes it.
+func (s server) setTag(key, value string) error {
+	s.tagMu.Lock()
+	defer s.tagMu.Unlock()
+	tags := make(map[string]string)
+	for k, v := range s.serf.LocalMember().Tags {
+		tags[k] = v
+	}
+	if value == "" {
+		delete(tags, key)
+	} else {
+		tags[key] = value
+	}
+	if err := s.serf.SetTags(tags); err != nil {
+		return fmt.Errorf("serf set tags: %w", err)
+	}
+	return nil
+}
+
+func (s server) handleBind(ev serf.UserEvent) {
+	var p bindPayload
+	if err := json.Unmarshal(ev.Payload, &p); err != nil {
+		slog.Warn("serf: bad bind payload", "err", err)
+		return
+	}
+	if p.Node != s.conf.name {
+		return
+	}
+	if err := s.setTag(TagActor, p.Actor); err != nil {
+		slog.Error("serf: bind actor", "actor", p.Actor, "err", err)
+		return
+	}
+	slog.Info("serf: bound to actor", "actor", p.Actor)
+}
@@ -0,0 +1,124 @@
+package transcript
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// Progress records the segments transcribed so far, so that an interrupted
+// run resumes with the chunks left. It is saved after each segment.
+type Progress struct {
+	path string
+	mu   sync.Mutex
+	// Recordings are keyed by their path.
+	Recordings map[string]*Recording `json:"recordings"`
+}
+
+// Recording is the progress of a recording, it restarts when the recording
+// or how it is split changed.
+type Recording struct {
+	Size    int64         `json:"size"`
+	ModTime time.Time     `json:"mod_time"`
+	Period  time.Duration `json:"period"`
+	// Segments are keyed by the index of their chunk.
+	Segments map[int]Segment `json:"segments"`
+}
+
+// OpenProgress reads the progress file p, the progress is empty when p
+// does not exist yet.
+func OpenProgress(p string) (*Progress, error) {
+	progress := &Progress{path: p, Recordings: make(map[string]*Recording)}
+	b, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return progress, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %q: %w", p, err)
+	}
+	if err := json.Unmarshal(b, progress); err != nil {
+		return nil, fmt.Errorf("decode %q: %w", p, err)
+	}
+	return progress, nil
+}
+
+// Begin starts or resumes the recording file split into chunks of period.
+func (p *Progress) Begin(file string, info fs.FileInfo, period time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, ok := p.Recordings[file]
+	if ok && r.Size == info.Size() && r.ModTime.Equal(info.ModTime()) && r.Period == period {
+		return
+	}
+	p.Recordings[file] = &Recording{
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+		Period:   period,
+		Segments: make(map[int]Segment),
+	}
+}
+
+// Segment is the segment of the chunk i of file, if it was transcribed.
+func (p *Progress) Segment(file string, i int) (Segment, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, ok := p.Recordings[file]
+	if !ok {
+		return Segment{}, false
+	}
+	s, ok := r.Segments[i]
+	return s, ok
+}
+
+// Add records the segment of the chunk i of file and saves the progress.
+func (p *Progress) Add(file string, i int, s Segment) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, ok := p.Recordings[file]
+	if !ok {
+		return fmt.Errorf("%q did not begin", file)
+	}
+	r.Segments[i] = s
+	return p.save()
+}
+
+// save writes the progress to a temporary file renamed over the previous
+// one, an interrupted save leaves the previous progress.
+func (p *Progress) save() error {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("encode progress: %w", err)
+	}
+	tmp := p.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open %q: %w", tmp, err)
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return fmt.Errorf("write %q: %w", tmp, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("sync %q: %w", tmp, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close %q: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, p.path); err != nil {
+		return fmt.Errorf("rename %q: %w", tmp, err)
+	}
+	return nil
+}
+
+// Remove deletes the progress file once the transcript is written.
+func (p *Progress) Remove() error {
+	if err := os.Remove(p.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove %q: %w", p.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package transcript
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Line is a chunk transcribed by the sidecar.
+type Line struct {
+	// Time is when the chunk was cut.
+	Time  time.Time `json:"time"`
+	Chunk string    `json:"chunk"`
+	// RequestID is the groq id of the transcription.
+	RequestID string `json:"request_id,omitempty"`
+	// Speaker labels the split channels, empty for a single track.
+	Speaker string `json:"speaker,omitempty"`
+	Text    string `json:"text"`
+}
+
+// ErrSinkClosed is returned writing to a closed sink.
+var ErrSinkClosed = errors.New("transcript sink closed")
+
+// Sink receives the transcribed lines in order, a line is acknowledged once
+// Write returns. A line written after Close fails with ErrSinkClosed.
+type Sink interface {
+	Write(l Line) error
+	Close() error
+}
+
+// LineFormat renders the lines of a transcript file.
+type LineFormat interface {
+	// Ext is the extension of the files, without dot.
+	Ext() string
+	// Header starts a file opened at t.
+	Header(w io.Writer, t time.Time) error
+	Line(w io.Writer, l Line) error
+}
+
+// LineFormats are the formats ParseLineFormat knows.
+var LineFormats = []string{"text", "jsonl", "markdown"}
+
+// ParseLineFormat returns the format named name, one of LineFormats.
+func ParseLineFormat(name string) (LineFormat, error) {
+	switch name {
+	case "text":
+		return Text{}, nil
+	case "jsonl":
+		return JSONLines{}, nil
+	case "markdown":
+		return Markdown{}, nil
+	}
+	return nil, fmt.Errorf("unknown line format %q", name)
+}
+
+// Text is a line per chunk prefixed by its time and speaker.
+type Text struct{}
+
+func (Text) Ext() string { return "txt" }
+
+func (Text) Header(io.Writer, time.Time) error { return nil }
+
+func (Text) Line(w io.Writer, l Line) error {
+	prefix := l.Time.Format(time.TimeOnly)
+	if l.Speaker != "" {
+		prefix += " " + l.Speaker + ":"
+	}
+	_, err := fmt.Fprintf(w, "[%s] %s\n", prefix, l.Text)
+	return err
+}
+
+// JSONLines is a json object per chunk.
+type JSONLines struct{}
+
+func (JSONLines) Ext() string { return "jsonl" }
+
+func (JSONLines) Header(io.Writer, time.Time) error { return nil }
+
+func (JSONLines) Line(w io.Writer, l Line) error {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("json marshal: %w", err)
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// Markdown is a list item per chunk under a title naming the file start.
+type Markdown struct{}
+
+func (Markdown) Ext() string { return "md" }
+
+func (Markdown) Header(w io.Writer, t time.Time) error {
+	_, err := fmt.Fprintf(w, "# Transcript %s\n\n", t.Format("2006-01-02 15:04:05"))
+	return err
+}
+
+func (Markdown) Line(w io.Writer, l Line) error {
+	speaker := ""
+	if l.Speaker != "" {
+		speaker = " **" + l.Speaker + "**"
+	}
+	_, err := fmt.Fprintf(w, "- `%s`%s %s\n", l.Time.Format(time.TimeOnly), speaker, l.Text)
+	return err
+}
+
+// FileSink writes the lines to files of dir named after their first line,
+// e.g. 2006-01-02_15-04-05.000.txt. Each line is synced to disk before
+// Write returns. It is safe for concurrent use.
+type FileSink struct {
+	dir    string
+	format LineFormat
+	every  time.Duration
+	size   int64
+
+	mu      sync.Mutex
+	closed  bool
+	f       *os.File
+	period  time.Time
+	written int64
+}
+
+type SinkOption func(*FileSink)
+
+// SinkOptionRotateEvery starts a new file when a line falls in another
+// period of d than the file, e.g. every hour.
+func SinkOptionRotateEvery(d time.Duration) SinkOption {
+	return func(s *FileSink) {
+		s.every = d
+	}
+}
+
+// SinkOptionRotateSize starts a new file once the file reached size bytes.
+func SinkOptionRotateSize(size int64) SinkOption {
+	return func(s *FileSink) {
+		s.size = size
+	}
+}
+
+// NewFileSink writes lines in format to dir, created on the first line.
+func NewFileSink(dir string, format LineFormat, opts ...SinkOption) *FileSink {
+	s := &FileSink{dir: dir, format: format}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *FileSink) Write(l Line) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrSinkClosed
+	}
+	if s.f != nil && s.rotate(l.Time) {
+		if err := s.closeFile(); err != nil {
+			return err
+		}
+	}
+	if s.f == nil {
+		if err := s.open(l.Time); err != nil {
+			return err
+		}
+	}
+	var b bytes.Buffer
+	if err := s.format.Line(&b, l); err != nil {
+		return err
+	}
+	n, err := s.f.Write(b.Bytes())
+	s.written += int64(n)
+	if err != nil {
+		return fmt.Errorf("write %q: %w", s.f.Name(), err)
+	}
+	if err := s.f.Sync(); err != nil {
+		return fmt.Errorf("sync %q: %w", s.f.Name(), err)
+	}
+	return nil
+}
+
+func (s *FileSink) rotate(t time.Time) bool {
+	return s.every > 0 && !t.Truncate(s.every).Equal(s.period) ||
+		s.size > 0 && s.written >= s.size
+}
+
+func (s *FileSink) open(t time.Time) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("mkdir %q: %w", s.dir, err)
+	}
+	p := filepath.Join(s.dir, t.Format("2006-01-02_15-04-05.000")+"."+s.format.Ext())
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open %q: %w", p, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("stat %q: %w", p, err)
+	}
+	s.f, s.written = f, info.Size()
+	if s.every > 0 {
+		s.period = t.Truncate(s.every)
+	}
+	if s.written > 0 {
+		return nil
+	}
+	var b bytes.Buffer
+	if err := s.format.Header(&b, t); err != nil {
+		return err
+	}
+	n, err := f.Write(b.Bytes())
+	s.written += int64(n)
+	if err != nil {
+		return fmt.Errorf("write %q: %w", p, err)
+	}
+	return nil
+}
+
+// Close closes the current file, the lines written after fail.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return s.closeFile()
+}
+
+// closeFile closes the current file, the next line opens a new one.
+func (s *FileSink) closeFile() error {
+	if s.f == nil {
+		return nil
+	}
+	f := s.f
+	s.f = nil
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close %q: %w", f.Name(), err)
+	}
+	return nil
+}
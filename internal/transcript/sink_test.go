@@ -0,0 +1,82 @@
+package transcript
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileSink(t *testing.T) {
+	dir := t.TempDir()
+	s := NewFileSink(dir, JSONLines{}, SinkOptionRotateEvery(time.Hour))
+	start := time.Date(2026, 10, 19, 14, 58, 0, 0, time.Local)
+	for i, text := range []string{"one", "two", "three"} {
+		l := Line{
+			Time:      start.Add(time.Duration(i) * time.Minute),
+			Chunk:     "20261019145800.flac",
+			RequestID: "req_" + text,
+			Text:      text,
+		}
+		if err := s.Write(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 {
+		t.Fatalf("got files %v, want a file per hour", names)
+	}
+	b, err := os.ReadFile(names[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("first hour has %d lines, want 2", len(lines))
+	}
+	var l Line
+	if err := json.Unmarshal([]byte(lines[1]), &l); err != nil {
+		t.Fatal(err)
+	}
+	if l.Text != "two" || l.RequestID != "req_two" || !l.Time.Equal(start.Add(time.Minute)) {
+		t.Errorf("second line %+v", l)
+	}
+
+	// the size rotation starts a file after the markdown header and line
+	s = NewFileSink(filepath.Join(dir, "md"), Markdown{}, SinkOptionRotateSize(1))
+	for i := range 2 {
+		if err := s.Write(Line{Time: start.Add(time.Duration(i) * time.Second), Speaker: "alice", Text: "hi"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write(Line{Time: start, Text: "late"}); !errors.Is(err, ErrSinkClosed) {
+		t.Errorf("write after close: got %v, want %v", err, ErrSinkClosed)
+	}
+	names, err = filepath.Glob(filepath.Join(dir, "md", "*.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 {
+		t.Fatalf("got files %v, want a file per line", names)
+	}
+	b, err = os.ReadFile(names[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "# Transcript 2026-10-19 14:58:00\n\n- `14:58:00` **alice** hi\n"; string(b) != want {
+		t.Errorf("got %q, want %q", b, want)
+	}
+}
@@ -0,0 +1,93 @@
+// Package transcript writes the transcripts of chunks: the lines of the
+// sidecar as they are transcribed and the transcript of recordings split
+// into chunks by groq transcribe.
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Formats are the formats Write supports.
+var Formats = []string{"text", "json", "srt"}
+
+// Segment is the transcript of a chunk of a recording, Start and End are
+// its offsets in the recording.
+type Segment struct {
+	File  string        `json:"file"`
+	Start time.Duration `json:"start"`
+	End   time.Duration `json:"end"`
+	Text  string        `json:"text"`
+}
+
+// Write writes segments, ordered by recording then offset, in format:
+//   - text is a line per segment, preceded by the recording name when there
+//     are several;
+//   - json is an array of segments, their offsets in nanoseconds;
+//   - srt is a subtitle per segment, the recordings follow each other.
+func Write(w io.Writer, format string, segments []Segment) error {
+	switch format {
+	case "text":
+		return writeText(w, segments)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if segments == nil {
+			segments = []Segment{}
+		}
+		if err := encoder.Encode(segments); err != nil {
+			return fmt.Errorf("json encode: %w", err)
+		}
+		return nil
+	case "srt":
+		return writeSRT(w, segments)
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+func writeText(w io.Writer, segments []Segment) error {
+	several := len(segments) > 0 && segments[0].File != segments[len(segments)-1].File
+	file := ""
+	for i, s := range segments {
+		if several && s.File != file {
+			if i > 0 {
+				if _, err := fmt.Fprintln(w); err != nil {
+					return err
+				}
+			}
+			if _, err := fmt.Fprintf(w, "# %s\n", s.File); err != nil {
+				return err
+			}
+			file = s.File
+		}
+		if _, err := fmt.Fprintln(w, s.Text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeSRT(w io.Writer, segments []Segment) error {
+	// offset shifts a recording after the end of the previous one
+	var offset, end time.Duration
+	file := ""
+	for i, s := range segments {
+		if s.File != file {
+			offset, file = end, s.File
+		}
+		end = max(end, offset+s.End)
+		if _, err := fmt.Fprintf(w, "%d\n%s --> %s\n%s\n\n",
+			i+1, srtTime(offset+s.Start), srtTime(offset+s.End), s.Text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// srtTime formats d as hh:mm:ss,mmm.
+func srtTime(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
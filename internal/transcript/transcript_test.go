@@ -0,0 +1,81 @@
+package transcript
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteSRT(t *testing.T) {
+	var b strings.Builder
+	if err := Write(&b, "srt", []Segment{
+		{File: "a.wav", Start: 0, End: 10 * time.Second, Text: "one"},
+		{File: "a.wav", Start: 10 * time.Second, End: 12500 * time.Millisecond, Text: "two"},
+		{File: "b.wav", Start: 0, End: time.Hour, Text: "three"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	want := `1
+00:00:00,000 --> 00:00:10,000
+one
+
+2
+00:00:10,000 --> 00:00:12,500
+two
+
+3
+00:00:12,500 --> 01:00:12,500
+three
+
+`
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	dir := t.TempDir()
+	rec := filepath.Join(dir, "a.wav")
+	if err := os.WriteFile(rec, []byte("riff"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(dir, "progress.json")
+	progress, err := OpenProgress(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	progress.Begin(rec, info, 10*time.Second)
+	if err := progress.Add(rec, 1, Segment{File: rec, Text: "two"}); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err := OpenProgress(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed.Begin(rec, info, 10*time.Second)
+	if s, ok := resumed.Segment(rec, 1); !ok || s.Text != "two" {
+		t.Errorf("resumed segment 1: %+v, %t", s, ok)
+	}
+	if _, ok := resumed.Segment(rec, 0); ok {
+		t.Error("resumed segment 0 was not transcribed")
+	}
+	// another split restarts the recording
+	resumed.Begin(rec, info, 5*time.Second)
+	if _, ok := resumed.Segment(rec, 1); ok {
+		t.Error("segment 1 kept after changing the split")
+	}
+
+	if err := resumed.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Errorf("progress not removed: %v", err)
+	}
+}